@@ -5,6 +5,8 @@ import (
 	"github.com/wxsatellite/goweb/framework"
 	"github.com/wxsatellite/goweb/framework/cobra"
 	"github.com/wxsatellite/goweb/framework/command"
+	"github.com/wxsatellite/goweb/framework/provider/config"
+	"os"
 	"time"
 )
 
@@ -35,6 +37,10 @@ func RunCommand(container framework.Container) (err error) {
 	// 绑定业务的命令
 	AddAppCommand(rootCommand)
 
+	// --config.a.b=value 形式的参数已经被配置服务处理，不交给 cobra 解析
+	_, args := config.ParseFlags(os.Args[1:])
+	rootCommand.SetArgs(args)
+
 	// 执行
 	return rootCommand.Execute()
 }
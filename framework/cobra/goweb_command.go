@@ -17,6 +17,11 @@ func (c *Command) Container() framework.Container {
 	return c.Root().container
 }
 
+// Inject 从根命令的容器中获取服务，填充 target 中带有 inject 标签的字段，在添加命令的时候调用
+func (c *Command) Inject(target interface{}) error {
+	return framework.Inject(c.Container(), target)
+}
+
 /*** 用于定时脚本 ***/
 
 // CronSpec 保存 cron 命令的信息，用于展示
@@ -2,6 +2,7 @@ package command
 
 import (
 	"context"
+	"fmt"
 	"github.com/wxsatellite/goweb/framework/cobra"
 	"github.com/wxsatellite/goweb/framework/provider/kernel"
 	"log"
@@ -39,6 +40,10 @@ var appStartCommand = &cobra.Command{
 
 		// 获取根command中存放的容器
 		container := cmd.Container()
+		// 有非延迟服务的依赖一直没有绑定的话，启动之前就报错
+		if err = container.Verify(); err != nil {
+			return err
+		}
 
 		// 从容器中获取web服务引擎
 		service := container.MustMake(kernel.Key).(kernel.Kernel)
@@ -53,7 +58,7 @@ var appStartCommand = &cobra.Command{
 		}()
 
 		// 创建信号等待，用于安全退出服务
-		quit := make(chan os.Signal)
+		quit := make(chan os.Signal, 1)
 		signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT)
 		// 只有监听到上面三个信号的时候才会继续走后面的逻辑
 		<-quit
@@ -67,6 +72,10 @@ var appStartCommand = &cobra.Command{
 			log.Fatal("Server Shutdown", err)
 		}
 
-		return
+		// 请求都处理完之后再关闭容器中的服务，刷新日志文件、关闭配置监听等
+		if err = container.Shutdown(timeoutCtx); err != nil {
+			return fmt.Errorf("container shutdown: %w", err)
+		}
+		return nil
 	},
 }
@@ -0,0 +1,354 @@
+package command
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/spf13/cast"
+	"github.com/wxsatellite/goweb/framework/cobra"
+	"github.com/wxsatellite/goweb/framework/provider/app"
+	"github.com/wxsatellite/goweb/framework/provider/config"
+	"github.com/wxsatellite/goweb/framework/provider/env"
+	"github.com/wxsatellite/goweb/framework/utils"
+	"gopkg.in/yaml.v2"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+)
+
+/****  配置相关命令行 ****/
+
+var (
+	configShowSecrets = false
+	configShowSource  = false
+	configDumpFormat  = "yaml"
+)
+
+// secretMask 敏感配置输出时的替换值
+const secretMask = "******"
+
+// secretWords 配置项的名字包含这些词的时候认为是敏感配置，默认不输出明文
+var secretWords = []string{"password", "passwd", "pwd", "secret", "token", "credential", "private_key", "access_key", "api_key"}
+
+func initConfigCommand() *cobra.Command {
+	configGetCommand.Flags().BoolVar(&configShowSecrets, "show-secrets", false, "输出敏感配置的明文")
+	configGetCommand.Flags().BoolVar(&configShowSource, "source", false, "同时按优先级输出每一层中的值以及所在的文件")
+	configListCommand.Flags().BoolVar(&configShowSecrets, "show-secrets", false, "输出敏感配置的明文")
+	configDumpCommand.Flags().BoolVar(&configShowSecrets, "show-secrets", false, "输出敏感配置的明文")
+	configDumpCommand.Flags().StringVarP(&configDumpFormat, "format", "f", "yaml", "输出格式，支持 yaml 和 json")
+	configCommand.AddCommand(configGetCommand)
+	configCommand.AddCommand(configListCommand)
+	configCommand.AddCommand(configDumpCommand)
+	configCommand.AddCommand(configValidateCommand)
+	configCommand.AddCommand(configEncryptCommand)
+	configCommand.AddCommand(configDecryptCommand)
+	return configCommand
+}
+
+var configCommand = &cobra.Command{
+	Use:   "config",
+	Short: "配置相关命令",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return cmd.Help()
+	},
+}
+
+// configGetCommand 获取某个配置，例如：./goweb config get "database.mysql"
+var configGetCommand = &cobra.Command{
+	Use:     "get",
+	Short:   "获取某个配置，输出环境变量替换之后的值",
+	Example: "./goweb config get \"database.mysql\"",
+	Args:    cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		configService := cmd.Container().MustMake(config.Key).(config.Config)
+		key := args[0]
+		if !configService.IsExist(key) {
+			return errors.New("配置不存在：" + key)
+		}
+
+		// 从完整的配置中取值，保证嵌套的 map 已经转换好，可以直接序列化
+		path := strings.Split(key, ".")
+		val := maskSecrets(configService, key, lookupConfig(configService.All(), path))
+		switch val.(type) {
+		case map[string]interface{}, []interface{}:
+			out, err := yaml.Marshal(val)
+			if err != nil {
+				return err
+			}
+			fmt.Print(string(out))
+		default:
+			fmt.Println(cast.ToString(val))
+		}
+		if configShowSource {
+			// 按优先级从低到高输出每一层的值，* 标记最终生效的一层
+			layers := configService.Layers(key)
+			outs := [][]string{{"", "LAYER", "FILE", "VALUE"}}
+			for i, item := range layers {
+				mark := ""
+				if i == len(layers)-1 {
+					mark = "*"
+				}
+				value := item.Value
+				if item.Encrypted && !configShowSecrets {
+					value = secretMask
+				} else {
+					value = maskSecrets(configService, key, value)
+				}
+				out := cast.ToString(value)
+				switch value.(type) {
+				case map[string]interface{}, []interface{}:
+					if content, err := json.Marshal(value); err == nil {
+						out = string(content)
+					}
+				}
+				outs = append(outs, []string{mark, item.Layer, item.File, out})
+			}
+			utils.PrettyPrint(outs)
+		}
+		return nil
+	},
+}
+
+// configListCommand 按文件列出所有配置项
+var configListCommand = &cobra.Command{
+	Use:   "list",
+	Short: "按配置文件列出所有配置项",
+	Run: func(cmd *cobra.Command, args []string) {
+		configService := cmd.Container().MustMake(config.Key).(config.Config)
+		all := configService.All()
+
+		files := make([]string, 0, len(all))
+		for file := range all {
+			files = append(files, file)
+		}
+		sort.Strings(files)
+
+		outs := [][]string{{"FILE", "KEY", "VALUE", "LAYER"}}
+		for _, file := range files {
+			for _, item := range flattenConfig(configService, file, all[file]) {
+				layer, _ := configService.Source(item[0])
+				outs = append(outs, []string{file, item[0], item[1], layer})
+			}
+		}
+		utils.PrettyPrint(outs)
+	},
+}
+
+// configDumpCommand 输出合并之后的完整配置
+var configDumpCommand = &cobra.Command{
+	Use:   "dump",
+	Short: "输出完整的配置",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		configService := cmd.Container().MustMake(config.Key).(config.Config)
+		all := maskSecrets(configService, "", configService.All())
+
+		var out []byte
+		var err error
+		switch configDumpFormat {
+		case "yaml":
+			out, err = yaml.Marshal(all)
+		case "json":
+			out, err = json.MarshalIndent(all, "", "  ")
+			out = append(out, '\n')
+		default:
+			return errors.New("不支持的输出格式：" + configDumpFormat)
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Print(string(out))
+		return nil
+	},
+}
+
+// configValidateCommand 校验当前环境的配置，有错误的时候以非 0 状态码退出，可以用于部署之前检查配置
+var configValidateCommand = &cobra.Command{
+	Use:   "validate",
+	Short: "校验当前环境的配置",
+	Long:  "检查配置文件是否都加载成功，并且使用通过 config.RegisterSchema 注册的结构体严格校验配置，包括 validate 标签以及结构体中不存在的字段",
+	Run: func(cmd *cobra.Command, args []string) {
+		configService := cmd.Container().MustMake(config.Key).(config.Config)
+		failed := false
+
+		outs := [][]string{{"KEY", "TYPE", "STATUS", "ERROR"}}
+		// 加载失败的配置文件
+		status := configService.ReloadStatus()
+		files := make([]string, 0, len(status.Errors))
+		for file := range status.Errors {
+			files = append(files, file)
+		}
+		sort.Strings(files)
+		for _, file := range files {
+			failed = true
+			outs = append(outs, []string{file, "file", "fail", status.Errors[file].Error()})
+		}
+		// 注册的配置结构体
+		for _, result := range config.ValidateSchemas(configService) {
+			if result.Err != nil {
+				failed = true
+				outs = append(outs, []string{result.Key, result.Type, "fail", strings.ReplaceAll(result.Err.Error(), "\n", "; ")})
+				continue
+			}
+			outs = append(outs, []string{result.Key, result.Type, "ok", ""})
+		}
+		utils.PrettyPrint(outs)
+
+		if failed {
+			fmt.Println("config validate failed")
+			os.Exit(1)
+		}
+		fmt.Println("config validate passed")
+	},
+}
+
+// configEncryptCommand 加密一个配置，输出的 enc(xxx) 可以直接写在配置文件中
+var configEncryptCommand = &cobra.Command{
+	Use:     "encrypt",
+	Short:   "加密一个配置，没有参数的时候从标准输入读取，避免明文出现在命令历史中",
+	Example: "echo -n \"123456\" | ./goweb config encrypt",
+	Args:    cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		key, err := configEncryptKey(cmd)
+		if err != nil {
+			return err
+		}
+		plaintext, err := argOrStdin(args)
+		if err != nil {
+			return err
+		}
+		encrypted, err := config.Encrypt(key, plaintext)
+		if err != nil {
+			return err
+		}
+		fmt.Println(encrypted)
+		return nil
+	},
+}
+
+// configDecryptCommand 解密一个 enc(xxx) 格式的配置
+var configDecryptCommand = &cobra.Command{
+	Use:     "decrypt",
+	Short:   "解密一个 enc(xxx) 格式的配置，没有参数的时候从标准输入读取",
+	Example: "./goweb config decrypt \"enc(xxx)\"",
+	Args:    cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		key, err := configEncryptKey(cmd)
+		if err != nil {
+			return err
+		}
+		encrypted, err := argOrStdin(args)
+		if err != nil {
+			return err
+		}
+		plaintext, err := config.Decrypt(key, encrypted)
+		if err != nil {
+			return err
+		}
+		fmt.Println(plaintext)
+		return nil
+	},
+}
+
+// configEncryptKey 和配置服务使用同样的方式获取密钥
+func configEncryptKey(cmd *cobra.Command) ([]byte, error) {
+	container := cmd.Container()
+	appService := container.MustMake(app.Key).(app.App)
+	envService := container.MustMake(env.Key).(env.Env)
+	key, err := config.LoadEncryptKey(appService.ConfigFolder(), envService.All())
+	if err == config.ErrNoEncryptKey {
+		return nil, errors.New("没有找到密钥，请设置环境变量 " + config.EncryptKeyEnv + " 或者 " + config.EncryptKeyFileEnv + "，或者在配置目录下创建 " + config.EncryptKeyFile + " 文件")
+	}
+	return key, err
+}
+
+// argOrStdin 优先使用命令行参数，没有参数的时候读取标准输入，去掉最后的换行
+func argOrStdin(args []string) (string, error) {
+	if len(args) > 0 {
+		return args[0], nil
+	}
+	content, err := ioutil.ReadAll(os.Stdin)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(content), "\r\n"), nil
+}
+
+// lookupConfig 按照路径从配置中取值
+func lookupConfig(tree map[string]interface{}, path []string) interface{} {
+	var val interface{} = tree
+	for _, name := range path {
+		m, ok := val.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		val = m[name]
+	}
+	return val
+}
+
+// flattenConfig 把嵌套的配置展开成点分割的 key 和值，按 key 排序
+func flattenConfig(configService config.Config, prefix string, val interface{}) (res [][2]string) {
+	m, ok := val.(map[string]interface{})
+	if !ok {
+		out := maskSecrets(configService, prefix, val)
+		if _, isSlice := out.([]interface{}); isSlice {
+			b, _ := json.Marshal(out)
+			return [][2]string{{prefix, string(b)}}
+		}
+		return [][2]string{{prefix, cast.ToString(out)}}
+	}
+
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		res = append(res, flattenConfig(configService, prefix+"."+key, m[key])...)
+	}
+	return
+}
+
+// maskSecrets 把敏感配置以及加密的配置替换成掩码，key 是 val 对应的配置路径，map 会递归处理
+func maskSecrets(configService config.Config, key string, val interface{}) interface{} {
+	if configShowSecrets {
+		return val
+	}
+	if isSecret(key[strings.LastIndex(key, ".")+1:]) || configService.IsEncrypted(key) {
+		if _, ok := val.(map[string]interface{}); !ok {
+			return secretMask
+		}
+	}
+	switch v := val.(type) {
+	case map[string]interface{}:
+		res := make(map[string]interface{}, len(v))
+		for name, item := range v {
+			if key == "" {
+				res[name] = maskSecrets(configService, name, item)
+				continue
+			}
+			res[name] = maskSecrets(configService, key+"."+name, item)
+		}
+		return res
+	case []interface{}:
+		res := make([]interface{}, len(v))
+		for i, item := range v {
+			res[i] = maskSecrets(configService, key, item)
+		}
+		return res
+	default:
+		return v
+	}
+}
+
+// isSecret 判断配置项的名字是否是敏感配置
+func isSecret(name string) bool {
+	name = strings.ToLower(name)
+	for _, word := range secretWords {
+		if strings.Contains(name, word) {
+			return true
+		}
+	}
+	return false
+}
@@ -1,6 +1,7 @@
 package command
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"github.com/erikdubbelboer/gspt"
@@ -9,7 +10,9 @@ import (
 	"github.com/wxsatellite/goweb/framework/provider/app"
 	"github.com/wxsatellite/goweb/framework/utils"
 	"io/ioutil"
+	"log"
 	"os"
+	"os/signal"
 	"path/filepath"
 	"strconv"
 	"syscall"
@@ -114,8 +117,8 @@ var cronStartCommand = &cobra.Command{
 			defer d.Release()
 			fmt.Println("daemon started")
 			gspt.SetProcTitle("goweb cron")
-			// 会阻塞
-			cmd.Root().Cron.Start()
+			// 会阻塞，直到收到退出信号
+			runCron(cmd)
 			return nil
 		}
 
@@ -130,11 +133,33 @@ var cronStartCommand = &cobra.Command{
 		// 设置之后会影响ps的最后一列：
 		//	501  6555   828   0  5:18PM ttys002    0:00.02 goweb cron
 		gspt.SetProcTitle("goweb cron")
-		cmd.Root().Cron.Run()
+		runCron(cmd)
 		return nil
 	},
 }
 
+// runCron 启动定时任务并阻塞，收到退出信号后等待正在执行的任务结束，再关闭容器中的服务
+func runCron(cmd *cobra.Command) {
+	cmd.Root().Cron.Start()
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT)
+	<-quit
+
+	timeoutCtx, cancelFunc := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelFunc()
+
+	// Stop 不会中断正在执行的任务，返回的 context 会在所有任务执行完之后结束
+	select {
+	case <-cmd.Root().Cron.Stop().Done():
+	case <-timeoutCtx.Done():
+	}
+
+	if err := cmd.Container().Shutdown(timeoutCtx); err != nil {
+		log.Println("Container Shutdown", err)
+	}
+}
+
 // 重新启动
 var cronRestartCommand = &cobra.Command{
 	Use:   "restart",
@@ -1,10 +1,13 @@
 package command
 
 import (
+	"errors"
 	"fmt"
 	"github.com/wxsatellite/goweb/framework/cobra"
 	"github.com/wxsatellite/goweb/framework/provider/env"
 	"github.com/wxsatellite/goweb/framework/utils"
+	"os"
+	"strconv"
 )
 
 /**
@@ -13,6 +16,8 @@ import (
 */
 func initEnvCommand() *cobra.Command {
 	envCommand.AddCommand(envListCommand)
+	envCommand.AddCommand(envGetCommand)
+	envCommand.AddCommand(envCheckCommand)
 	return envCommand
 }
 
@@ -35,8 +40,60 @@ var envListCommand = &cobra.Command{
 		envs := envService.All()
 		var outs [][]string
 		for k, v := range envs {
+			// 从密钥文件中读取的环境变量不输出明文，需要的时候使用 env get 获取
+			if envService.IsSecret(k) {
+				v = secretMask
+			}
 			outs = append(outs, []string{k, v})
 		}
 		utils.PrettyPrint(outs)
 	},
 }
+
+// envGetCommand 获取某个环境变量以及它的来源，例如：./goweb env get DB_HOST
+var envGetCommand = &cobra.Command{
+	Use:     "get",
+	Short:   "获取某个环境变量，同时输出来自哪个 .env 文件还是运行环境变量",
+	Example: "./goweb env get DB_HOST",
+	Args:    cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		envService := cmd.Container().MustMake(env.Key).(env.Env)
+		key := args[0]
+		if !envService.IsExist(key) {
+			return errors.New("环境变量不存在：" + key)
+		}
+		fmt.Println(envService.Get(key))
+		fmt.Println("# source: " + envService.Source(key))
+		return nil
+	},
+}
+
+// envCheckCommand 检查通过 env.Declare 声明的环境变量，存在缺失或者不合法的时候退出码为 1
+var envCheckCommand = &cobra.Command{
+	Use:   "check",
+	Short: "检查声明的环境变量是否都已经设置并且合法",
+	Long:  "检查应用和服务提供者通过 env.Declare 声明的环境变量，必须的环境变量没有设置，或者值不符合声明的类型的时候报错，适合在部署之前执行",
+	Run: func(cmd *cobra.Command, args []string) {
+		envService := cmd.Container().MustMake(env.Key).(env.Env)
+		failed := false
+
+		outs := [][]string{{"NAME", "REQUIRED", "SOURCE", "STATUS", "DESCRIPTION"}}
+		for _, result := range env.Check(envService) {
+			status := "ok"
+			if result.Err != nil {
+				failed = true
+				status = "fail: " + result.Err.Error()
+			} else if result.Value == "" {
+				status = "unset"
+			}
+			outs = append(outs, []string{result.Var.Name, strconv.FormatBool(result.Var.Required), result.Source, status, result.Var.Description})
+		}
+		utils.PrettyPrint(outs)
+
+		if failed {
+			fmt.Println("env check failed")
+			os.Exit(1)
+		}
+		fmt.Println("env check passed")
+	},
+}
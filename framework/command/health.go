@@ -0,0 +1,58 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"github.com/wxsatellite/goweb/framework/cobra"
+	"github.com/wxsatellite/goweb/framework/provider/health"
+	"github.com/wxsatellite/goweb/framework/utils"
+	"os"
+)
+
+/****  健康检查命令行 ****/
+
+func initHealthCommand() *cobra.Command {
+	return healthCommand
+}
+
+// healthCommand 执行和 /healthz、/readyz 一样的检查，有检查项失败的时候以非 0 状态码退出，可以用于部署脚本
+var healthCommand = &cobra.Command{
+	Use:   "health",
+	Short: "执行健康检查",
+	Long:  "执行所有的存活检查和就绪检查，有检查项失败的时候以非 0 状态码退出",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		container := cmd.Container()
+
+		// 没有绑定健康检查服务的时候，临时创建一个，依然可以执行打了标签的服务的检查
+		var service health.Health
+		if container.IsBind(health.Key) {
+			service = container.MustMake(health.Key).(health.Health)
+		} else {
+			instance, err := health.New(container, health.DefaultTimeout)
+			if err != nil {
+				return err
+			}
+			service = instance.(health.Health)
+		}
+
+		ctx := context.Background()
+		liveness := service.Liveness(ctx)
+		readiness := service.Readiness(ctx)
+
+		outs := [][]string{{"TYPE", "NAME", "STATUS", "ELAPSED", "ERROR"}}
+		for _, result := range liveness.Checks {
+			outs = append(outs, []string{"liveness", result.Name, result.Status, result.Elapsed, result.Error})
+		}
+		for _, result := range readiness.Checks {
+			outs = append(outs, []string{"readiness", result.Name, result.Status, result.Elapsed, result.Error})
+		}
+		utils.PrettyPrint(outs)
+
+		if !liveness.IsOk() || !readiness.IsOk() {
+			fmt.Println("health check failed")
+			os.Exit(1)
+		}
+		fmt.Println("health check passed")
+		return nil
+	},
+}
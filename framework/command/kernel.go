@@ -12,5 +12,17 @@ func AddKernelCommands(rootCommand *cobra.Command) {
 
 	// env
 	rootCommand.AddCommand(initEnvCommand())
+
+	// config
+	rootCommand.AddCommand(initConfigCommand())
+
+	// provider
+	rootCommand.AddCommand(initProviderCommand())
+
+	// health
+	rootCommand.AddCommand(initHealthCommand())
+
+	// version
+	rootCommand.AddCommand(initVersionCommand())
 	return
 }
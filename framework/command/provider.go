@@ -0,0 +1,93 @@
+package command
+
+import (
+	"errors"
+	"fmt"
+	"github.com/wxsatellite/goweb/framework/cobra"
+	"github.com/wxsatellite/goweb/framework/utils"
+	"strconv"
+	"strings"
+)
+
+/****  服务提供者命令行，用于查看容器中绑定了哪些服务 ****/
+
+var providerGraphFormat = "text"
+
+func initProviderCommand() *cobra.Command {
+	providerGraphCommand.Flags().StringVarP(&providerGraphFormat, "format", "f", "text", "输出格式，支持 text 和 dot（Graphviz）")
+	providerCommand.AddCommand(providerListCommand)
+	providerCommand.AddCommand(providerGraphCommand)
+	return providerCommand
+}
+
+var providerCommand = &cobra.Command{
+	Use:   "provider",
+	Short: "服务提供者相关命令",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return cmd.Help()
+	},
+}
+
+// 列出容器中所有绑定的服务提供者
+var providerListCommand = &cobra.Command{
+	Use:   "list",
+	Short: "列出所有绑定的服务提供者",
+	Run: func(cmd *cobra.Command, args []string) {
+		container := cmd.Container()
+		outs := [][]string{{"KEY", "DEFER", "SCOPED", "INSTANTIATED", "TYPE"}}
+		for _, info := range container.Providers() {
+			instanceType := info.InstanceType
+			if instanceType == "" {
+				instanceType = "-"
+			}
+			outs = append(outs, []string{
+				info.Key,
+				strconv.FormatBool(info.IsDefer),
+				strconv.FormatBool(info.IsScoped),
+				strconv.FormatBool(info.Instantiated),
+				instanceType,
+			})
+		}
+		utils.PrettyPrint(outs)
+		// 还在等待依赖的非延迟服务
+		if err := container.Verify(); err != nil {
+			fmt.Println(err)
+		}
+	},
+}
+
+// 打印服务提供者之间的依赖关系
+var providerGraphCommand = &cobra.Command{
+	Use:   "graph",
+	Short: "打印服务提供者的依赖关系图",
+	Long:  "打印服务提供者的依赖关系图，只有实现了 Depends 方法的服务提供者才有依赖信息，dot 格式可以通过 `dot -Tpng` 生成图片",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		infos := cmd.Container().Providers()
+		switch providerGraphFormat {
+		case "text":
+			// 每一行是一个服务以及它依赖的服务：goweb:log -> goweb:app, goweb:config
+			for _, info := range infos {
+				if len(info.Depends) == 0 {
+					fmt.Println(info.Key)
+					continue
+				}
+				fmt.Println(info.Key + " -> " + strings.Join(info.Depends, ", "))
+			}
+		case "dot":
+			fmt.Println("digraph goweb {")
+			for _, info := range infos {
+				if len(info.Depends) == 0 {
+					fmt.Printf("\t%q;\n", info.Key)
+					continue
+				}
+				for _, depend := range info.Depends {
+					fmt.Printf("\t%q -> %q;\n", info.Key, depend)
+				}
+			}
+			fmt.Println("}")
+		default:
+			return errors.New("不支持的输出格式：" + providerGraphFormat)
+		}
+		return nil
+	},
+}
@@ -0,0 +1,47 @@
+package command
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/wxsatellite/goweb/framework/cobra"
+	"github.com/wxsatellite/goweb/framework/provider/app"
+	"github.com/wxsatellite/goweb/framework/utils"
+)
+
+/****  版本相关命令行 ****/
+
+var versionJson = false
+
+func initVersionCommand() *cobra.Command {
+	versionCommand.Flags().BoolVar(&versionJson, "json", false, "以 json 格式输出")
+	return versionCommand
+}
+
+// versionCommand 输出应用的构建信息，例如：./goweb version
+var versionCommand = &cobra.Command{
+	Use:   "version",
+	Short: "输出版本、git commit、构建时间以及 Go 版本",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// 没有绑定应用服务的时候，输出程序本身的构建信息
+		info := app.GetBuildInfo()
+		if container := cmd.Container(); container != nil && container.IsBind(app.Key) {
+			info = container.MustMake(app.Key).(app.App).BuildInfo()
+		}
+
+		if versionJson {
+			out, err := json.MarshalIndent(info, "", "  ")
+			if err != nil {
+				return err
+			}
+			fmt.Println(string(out))
+			return nil
+		}
+		utils.PrettyPrint([][]string{
+			{"Version:", info.Version},
+			{"Git commit:", info.GitCommit},
+			{"Build time:", info.BuildTime},
+			{"Go version:", info.GoVersion},
+		})
+		return nil
+	},
+}
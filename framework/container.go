@@ -1,8 +1,13 @@
 package framework
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"sort"
+	"strings"
 	"sync"
+	"time"
 )
 
 type Container interface {
@@ -20,6 +25,72 @@ type Container interface {
 
 	// MakeNew 获取服务实例，只是这个服务并不是单例模式的，它是根据服务提供者和传递的 params 参数实例化出来的
 	MakeNew(key string, params []interface{}) (interface{}, error)
+
+	// Shutdown 按照创建顺序的逆序关闭所有已经实例化的服务，释放文件句柄、监听器等资源
+	Shutdown(ctx context.Context) error
+
+	// ResolveAll 按照绑定的顺序获取打了 tag 标签的所有服务
+	ResolveAll(tag string) ([]interface{}, error)
+
+	// Alias 为已经绑定（或者之后会绑定）的服务凭证 key 设置别名，通过别名获取的是同一个服务
+	Alias(alias string, key string) error
+
+	// Extend 为服务注册装饰器，服务每次实例化之后（包括 MakeNew）都会按照注册顺序经过装饰器包装，
+	// 如果服务已经实例化，会立即包装已有的单例
+	Extend(key string, decorator Decorator) error
+
+	// Providers 获取所有绑定的服务提供者的运行时信息，按照凭证排序
+	Providers() []ProviderInfo
+
+	// AddHook 添加容器事件的钩子，可以观察绑定、Boot、实例化的耗时和错误，添加的时候会先回放已经发生的事件
+	AddHook(hook Hook)
+
+	// Verify 检查是否有非延迟服务还没有实例化，比如还在等待没有绑定的依赖，一般在所有服务提供者绑定完成之后调用
+	Verify() error
+
+	// NewScope 创建一个子容器，子容器继承当前容器的所有绑定，作用域服务在子容器中单独实例化并缓存，
+	// 子容器使用完之后需要调用 Shutdown 释放作用域服务
+	NewScope() Container
+}
+
+// Decorator 服务装饰器，传入实例化好的服务，返回包装之后的服务，比如给分布式锁服务加上监控、给日志服务加上脱敏
+// 返回的服务需要实现原服务的接口，如果原服务实现了 Closer 或 Stopper，包装之后的服务也需要转发
+type Decorator func(instance interface{}, container Container) (interface{}, error)
+
+// ProviderInfo 服务提供者的运行时信息，用于 goweb provider 命令展示容器中绑定了什么
+type ProviderInfo struct {
+	Key          string   // 服务凭证
+	IsDefer      bool     // 是否延迟实例化
+	IsScoped     bool     // 是否是作用域服务
+	Instantiated bool     // 是否已经实例化
+	InstanceType string   // 实例的具体类型，比如 *config.Service，没有实例化的时候为空
+	Depends      []string // 声明的依赖，没有实现 DependentProvider 的时候为空
+	Tags         []string // 所属的标签
+	Pending      bool     // 非延迟服务是否还在等待依赖绑定
+}
+
+// Closer 服务实例实现了这个接口，容器 Shutdown 的时候会调用 Close 释放资源
+type Closer interface {
+	Close() error
+}
+
+// Stopper 需要感知超时的服务实例可以实现这个接口，容器 Shutdown 的时候会调用 Stop，ctx 超时之后 Stop 应该尽快返回
+type Stopper interface {
+	Stop(ctx context.Context) error
+}
+
+// DefaultShutdownTimeout 每个服务关闭的默认超时时间
+const DefaultShutdownTimeout = 5 * time.Second
+
+// ShutdownError 聚合了 Shutdown 过程中每个服务关闭返回的错误
+type ShutdownError []error
+
+func (e ShutdownError) Error() string {
+	msgs := make([]string, 0, len(e))
+	for _, err := range e {
+		msgs = append(msgs, err.Error())
+	}
+	return "shutdown error: " + strings.Join(msgs, "; ")
 }
 
 var _ Container = (*GoWebContainer)(nil)
@@ -31,6 +102,29 @@ type GoWebContainer struct {
 	providers map[string]ServiceProvider
 	// instance 存储具体的实例，key 为字符串凭证
 	instances map[string]interface{}
+	// tags 存储每个标签下的服务凭证，按照绑定的顺序排列
+	tags map[string][]string
+	// aliases 存储别名指向的服务凭证
+	aliases map[string]string
+	// decorators 存储每个服务凭证的装饰器，按照注册的顺序排列
+	decorators map[string][]Decorator
+	// pending 存储还在等待依赖绑定的非延迟服务凭证，按照绑定的顺序排列
+	pending []string
+	// creating 每个凭证一把锁，保证同一个服务只会被实例化一次
+	// 实例化的时候不能持有 lock，因为 Boot、Params 中还会通过容器获取其他服务
+	creating map[string]*sync.Mutex
+	// created 按照实例化的顺序存储单例的凭证，Shutdown 的时候逆序关闭
+	created []string
+	// shutdownTimeout 每个服务关闭的超时时间
+	shutdownTimeout time.Duration
+	// hooks 容器事件的钩子
+	hooks []Hook
+	// history 根容器已经发生的事件，添加钩子的时候回放，最多保留 maxEventHistory 个
+	history []Event
+	// parent 父容器，根容器为 nil。子容器中找不到的服务提供者会去父容器中查找
+	parent *GoWebContainer
+	// closed 子容器 Shutdown 之后不再实例化服务，否则新实例化的作用域服务没有人负责关闭
+	closed bool
 	// lock 容器的场景是读多写少的，因此使用读些锁而不是互斥锁
 	lock sync.RWMutex
 }
@@ -39,33 +133,188 @@ func NewGoWebContainer() *GoWebContainer {
 	return &GoWebContainer{
 		providers: make(map[string]ServiceProvider),
 		instances: make(map[string]interface{}),
+		tags:      make(map[string][]string),
+		aliases:   make(map[string]string),
+		creating:  make(map[string]*sync.Mutex),
 		lock:      sync.RWMutex{},
+
+		decorators:      make(map[string][]Decorator),
+		shutdownTimeout: DefaultShutdownTimeout,
 	}
 }
 
+// NewScope 创建子容器，比如每个 HTTP 请求一个子容器
+// 子容器中绑定的服务和作用域服务（实现 ScopedProvider 并返回 true）由子容器自己实例化和缓存，其他服务还是使用父容器中的单例
+func (c *GoWebContainer) NewScope() Container {
+	child := NewGoWebContainer()
+	child.parent = c
+	c.lock.RLock()
+	child.shutdownTimeout = c.shutdownTimeout
+	c.lock.RUnlock()
+	return child
+}
+
 // Bind 绑定服务提供者
+// 非延迟的服务会在依赖全部绑定之后按照拓扑顺序实例化，依赖还没有绑定的话就先等待，直到依赖被绑定
+// 重新绑定已经实例化的服务时，会关闭并丢弃旧的单例，之后获取的是新的服务提供者实例化的服务
 func (c *GoWebContainer) Bind(provider ServiceProvider) (err error) {
+	key := provider.Name()
 
-	// Bind 是全局的，不存在并发安全问题，因此读锁即可。但是 make 方法是请求级别的，因此存在并发问题
+	c.lock.Lock()
+	c.providers[key] = provider
+	if tp, ok := provider.(TaggedProvider); ok {
+		c.tag(key, tp.Tags()...)
+	}
+	old, rebind := c.instances[key]
+	if rebind {
+		delete(c.instances, key)
+		c.created = removeKey(c.created, key)
+	}
+	c.pending = removeKey(c.pending, key)
+	timeout := c.shutdownTimeout
+	if !provider.IsDefer() {
+		c.pending = append(c.pending, key)
+	}
+	c.lock.Unlock()
+
+	if rebind {
+		// 关闭失败不影响新的绑定，通过钩子通知
+		if closeErr := closeInstance(context.Background(), old, timeout); closeErr != nil {
+			c.emit(Event{Type: EventError, Key: key, Err: fmt.Errorf("close replaced instance: %w", closeErr)})
+		}
+	}
+	c.emit(Event{Type: EventBind, Key: key})
+	// 延迟的服务自己不实例化，但可能是其他等待中的服务的依赖，所以每次绑定都要处理等待的服务
+	return c.bootPending()
+}
+
+// removeKey 从凭证列表中删除 key，返回新的列表
+func removeKey(keys []string, key string) []string {
+	res := make([]string, 0, len(keys))
+	for _, k := range keys {
+		if k != key {
+			res = append(res, k)
+		}
+	}
+	return res
+}
+
+// Verify 检查当前容器中所有还没有实例化的非延迟服务，依赖缺少或者有环的报告解析依赖的错误，
+// 依赖都已经绑定但还是没有实例化的（比如实例化失败）也会报告，所有的错误合并成一个返回
+func (c *GoWebContainer) Verify() error {
 	c.lock.RLock()
-	defer c.lock.RUnlock()
+	var keys []string
+	for key, provider := range c.providers {
+		if _, ok := c.instances[key]; !ok && !provider.IsDefer() {
+			keys = append(keys, key)
+		}
+	}
+	c.lock.RUnlock()
+	sort.Strings(keys)
 
-	key := provider.Name()
+	msgs := make([]string, 0, len(keys))
+	for _, key := range keys {
+		if _, err := c.resolveOrder(key); err != nil {
+			msgs = append(msgs, err.Error())
+		} else {
+			msgs = append(msgs, "contract "+key+" is not instantiated")
+		}
+	}
+	if len(msgs) > 0 {
+		return errors.New("pending contracts: " + strings.Join(msgs, "; "))
+	}
+	return nil
+}
 
-	c.providers[key] = provider
+// Tag 给已经绑定的服务凭证打上标签，用于没有实现 TaggedProvider 的服务提供者
+func (c *GoWebContainer) Tag(tag string, keys ...string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	for _, key := range keys {
+		c.tag(key, tag)
+	}
+}
 
-	// 延迟实例化就直接返回
-	if provider.IsDefer() {
-		return
+// tag 把 key 加入到每个标签中，重复绑定的时候保持第一次绑定的位置，调用方需要持有写锁
+func (c *GoWebContainer) tag(key string, tags ...string) {
+	for _, tag := range tags {
+		exist := false
+		for _, k := range c.tags[tag] {
+			if k == key {
+				exist = true
+				break
+			}
+		}
+		if !exist {
+			c.tags[tag] = append(c.tags[tag], key)
+		}
 	}
-	instance, err := c.newInstance(provider, nil)
-	if err != nil {
-		return
+}
+
+// ResolveAll 按照绑定的顺序获取标签下的所有服务，子容器中先是父容器的服务，再是自己绑定的服务
+// 任何一个服务获取失败都会返回错误
+func (c *GoWebContainer) ResolveAll(tag string) (res []interface{}, err error) {
+	for _, key := range c.taggedKeys(tag) {
+		var instance interface{}
+		if instance, err = c.make(key, nil, false); err != nil {
+			return nil, err
+		}
+		res = append(res, instance)
 	}
-	c.instances[key] = instance
 	return
 }
 
+// taggedKeys 获取标签下的所有服务凭证
+func (c *GoWebContainer) taggedKeys(tag string) (keys []string) {
+	if c.parent != nil {
+		keys = c.parent.taggedKeys(tag)
+	}
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return append(keys, c.tags[tag]...)
+}
+
+// Alias 设置别名，别名不能和已经绑定的服务凭证重名，也不能形成环
+func (c *GoWebContainer) Alias(alias string, key string) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if _, ok := c.providers[alias]; ok {
+		return errors.New("alias " + alias + " is already bound as a contract")
+	}
+	for target, ok := key, true; ok; target, ok = c.aliases[target] {
+		if target == alias {
+			return errors.New("alias " + alias + " -> " + key + " is circular")
+		}
+	}
+	c.aliases[alias] = key
+	return nil
+}
+
+// realKey 获取别名最终指向的服务凭证
+func (c *GoWebContainer) realKey(key string) string {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.resolveAlias(key)
+}
+
+// resolveAlias 沿着别名找到最终的服务凭证，当前容器找不到再去父容器找，调用方需要持有读锁
+func (c *GoWebContainer) resolveAlias(key string) string {
+	for {
+		if _, ok := c.providers[key]; ok {
+			return key
+		}
+		target, ok := c.aliases[key]
+		if !ok {
+			break
+		}
+		key = target
+	}
+	if c.parent != nil {
+		return c.parent.realKey(key)
+	}
+	return key
+}
+
 // Make 创建服务，会缓存
 func (c *GoWebContainer) Make(key string) (res interface{}, err error) {
 	return c.make(key, nil, false)
@@ -94,58 +343,284 @@ func (c *GoWebContainer) IsBind(key string) bool {
 func (c *GoWebContainer) findServiceProvider(key string) (sp ServiceProvider) {
 	c.lock.RLock()
 	defer c.lock.RUnlock()
-	sp, _ = c.providers[key]
+	sp, _ = c.lookupProvider(c.resolveAlias(key))
 	return
 }
 
+// lookupProvider 先在当前容器中查找服务提供者，找不到再去父容器中查找，调用方需要持有读锁
+// 传入的 key 需要是已经解析过别名的凭证
+func (c *GoWebContainer) lookupProvider(key string) (sp ServiceProvider, ok bool) {
+	if sp, ok = c.providers[key]; ok || c.parent == nil {
+		return
+	}
+	sp = c.parent.findServiceProvider(key)
+	return sp, sp != nil
+}
+
+// ownInstance 判断服务的实例是否由当前容器持有，调用方需要持有读锁
+// 根容器持有所有的实例，子容器只持有自己绑定的服务和作用域服务的实例
+func (c *GoWebContainer) ownInstance(key string, sp ServiceProvider) bool {
+	if c.parent == nil {
+		return true
+	}
+	if _, ok := c.providers[key]; ok {
+		return true
+	}
+	scoped, ok := sp.(ScopedProvider)
+	return ok && scoped.IsScoped()
+}
+
+// delegate 获取处理不归当前容器持有的服务的父容器。
+// 父容器已经关闭的时候，只有父容器自己绑定的服务不能再获取，其他服务交给更上层还没有关闭的容器处理，
+// 比如请求结束之后，Copy 出来的 Context 还可以继续获取全局服务
+func (c *GoWebContainer) delegate(key string) *GoWebContainer {
+	parent := c.parent
+	for parent.parent != nil {
+		parent.lock.RLock()
+		_, bound := parent.providers[key]
+		skip := parent.closed && !bound
+		parent.lock.RUnlock()
+		if !skip {
+			break
+		}
+		parent = parent.parent
+	}
+	return parent
+}
+
 // make 实例化操作
 func (c *GoWebContainer) make(key string, params []interface{}, forceNew bool) (res interface{}, err error) {
 	c.lock.RLock()
-
-	// 不强制重新初始化，就获取容器中存在的实例
-	res, ok := c.instances[key]
-	if ok {
+	if c.closed {
 		c.lock.RUnlock()
+		err = errors.New("scope is shut down, contract " + key + " can not be made")
 		return
 	}
-
+	key = c.resolveAlias(key)
 	// 获取服务提供者
-	provider := c.findServiceProvider(key)
-	if provider == nil {
+	provider, ok := c.lookupProvider(key)
+	if !ok {
 		c.lock.RUnlock()
 		err = errors.New("contract " + key + " have not register")
 		return
 	}
-	// 关闭读锁
+	// 不归当前容器持有的服务交给父容器处理
+	if !c.ownInstance(key, provider) {
+		c.lock.RUnlock()
+		return c.delegate(key).make(key, params, forceNew)
+	}
+	// 不强制重新初始化，就获取容器中存在的实例
+	if !forceNew {
+		if res, ok = c.instances[key]; ok {
+			c.lock.RUnlock()
+			return
+		}
+	}
 	c.lock.RUnlock()
 
-	// 加写锁，双重检查，在获得写锁之后，可能有别的协程已经创建完成，可以直接返回，避免再创建
-	c.lock.Lock()
-	defer c.lock.Unlock()
-	res, ok = c.instances[key]
-	if ok {
+	// 按照拓扑顺序先实例化依赖的服务，最后一个就是 key 自己
+	order, err := c.resolveOrder(key)
+	if err != nil {
+		c.emit(Event{Type: EventError, Key: key, Err: err})
 		return
 	}
+	for _, depend := range order[:len(order)-1] {
+		if _, err = c.instance(depend); err != nil {
+			return
+		}
+	}
 
 	if forceNew {
 		return c.newInstance(provider, params)
 	}
+	return c.instance(key)
+}
 
-	// 如果容器不存在就新增一个
-	newInstance, err := c.newInstance(provider, params)
-	if err != nil {
+// instance 获取单例，不存在就实例化并缓存
+func (c *GoWebContainer) instance(key string) (res interface{}, err error) {
+	mu := c.creatingLock(key)
+	mu.Lock()
+	defer mu.Unlock()
+
+	// 双重检查，在获得锁之后，可能有别的协程已经创建完成，可以直接返回，避免再创建
+	c.lock.RLock()
+	res, ok := c.instances[key]
+	provider, _ := c.lookupProvider(key)
+	own := c.ownInstance(key, provider)
+	closed := c.closed
+	c.lock.RUnlock()
+	if ok {
+		return
+	}
+	if !own {
+		return c.parent.instance(key)
+	}
+	if closed {
+		err = errors.New("scope is shut down, contract " + key + " can not be made")
+		return
+	}
+
+	if res, err = c.newInstance(provider, nil); err != nil {
 		return
 	}
-	c.instances[key] = newInstance
-	res = newInstance
+	c.lock.Lock()
+	c.instances[key] = res
+	c.created = append(c.created, key)
+	c.lock.Unlock()
+	return
+}
+
+// creatingLock 获取凭证对应的实例化锁
+func (c *GoWebContainer) creatingLock(key string) *sync.Mutex {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	mu, ok := c.creating[key]
+	if !ok {
+		mu = &sync.Mutex{}
+		c.creating[key] = mu
+	}
+	return mu
+}
+
+// bootPending 实例化依赖已经全部绑定的非延迟服务
+func (c *GoWebContainer) bootPending() error {
+	c.lock.Lock()
+	pending := c.pending
+	c.pending = nil
+	c.lock.Unlock()
+
+	var waiting []string
+	var err error
+	for _, key := range pending {
+		if err != nil {
+			waiting = append(waiting, key)
+			continue
+		}
+		// 依赖还没有全部绑定，继续等待
+		if !c.dependsBound(key) {
+			waiting = append(waiting, key)
+			continue
+		}
+		_, err = c.make(key, nil, false)
+	}
+
+	// 等待期间可能又有新的服务绑定进来，放在后面
+	c.lock.Lock()
+	c.pending = append(waiting, c.pending...)
+	c.lock.Unlock()
+	return err
+}
+
+// dependsBound 判断服务的所有依赖（包括间接依赖）是否都已经绑定
+func (c *GoWebContainer) dependsBound(key string) bool {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	visited := map[string]bool{}
+	var visit func(key string) bool
+	visit = func(key string) bool {
+		if visited[key] {
+			return true
+		}
+		visited[key] = true
+		provider, ok := c.lookupProvider(key)
+		if !ok {
+			return false
+		}
+		for _, depend := range providerDepends(provider) {
+			if !visit(c.resolveAlias(depend)) {
+				return false
+			}
+		}
+		return true
+	}
+	return visit(key)
+}
+
+// resolveOrder 深度优先遍历 key 的依赖，返回拓扑排序后的凭证，依赖在前，key 自己在最后
+// 如果依赖没有绑定或者依赖之间存在环，返回错误
+func (c *GoWebContainer) resolveOrder(key string) (order []string, err error) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	const (
+		visiting = 1
+		visited  = 2
+	)
+	state := map[string]int{}
+	var path []string
+
+	var visit func(key string) error
+	visit = func(key string) error {
+		switch state[key] {
+		case visited:
+			return nil
+		case visiting:
+			// 从第一次出现的位置截取，就是环
+			start := 0
+			for i, k := range path {
+				if k == key {
+					start = i
+					break
+				}
+			}
+			cycle := append(append([]string{}, path[start:]...), key)
+			return errors.New("contract " + key + " has circular dependency: " + strings.Join(cycle, " -> "))
+		}
+
+		provider, ok := c.lookupProvider(key)
+		if !ok {
+			if len(path) == 0 {
+				return errors.New("contract " + key + " have not register")
+			}
+			return errors.New("contract " + path[len(path)-1] + " depends on " + key + ", which have not register")
+		}
+
+		state[key] = visiting
+		path = append(path, key)
+		for _, depend := range providerDepends(provider) {
+			if err := visit(c.resolveAlias(depend)); err != nil {
+				return err
+			}
+		}
+		path = path[:len(path)-1]
+		state[key] = visited
+		order = append(order, key)
+		return nil
+	}
+
+	err = visit(key)
 	return
 }
 
+// providerDepends 获取服务提供者声明的依赖，没有实现 DependentProvider 的服务提供者没有依赖
+func providerDepends(sp ServiceProvider) []string {
+	if dp, ok := sp.(DependentProvider); ok {
+		return dp.Depends()
+	}
+	return nil
+}
+
 // newInstance 实例化操作
 func (c *GoWebContainer) newInstance(sp ServiceProvider, params []interface{}) (res interface{}, err error) {
+	// 测试替身直接返回，不经过 Boot、Register 和装饰器
+	if fake, ok := sp.(*fakeProvider); ok {
+		return fake.instance, nil
+	}
+
+	key := sp.Name()
+	start := time.Now()
+	defer func() {
+		if err != nil {
+			c.emit(Event{Type: EventError, Key: key, Elapsed: time.Since(start), Err: err})
+		}
+	}()
+
+	c.emit(Event{Type: EventBeforeBoot, Key: key})
 	if err = sp.Boot(c); err != nil {
 		return
 	}
+	c.emit(Event{Type: EventAfterBoot, Key: key, Elapsed: time.Since(start)})
 
 	// 如果没有指定的实例化参数，就获取sp自带的
 	if params == nil {
@@ -156,6 +631,242 @@ func (c *GoWebContainer) newInstance(sp ServiceProvider, params []interface{}) (
 	newInstanceMethod := sp.Register(c)
 
 	// 实例化
-	res, err = newInstanceMethod(params...)
+	if res, err = newInstanceMethod(params...); err != nil {
+		return
+	}
+
+	// 依次经过装饰器包装
+	for _, decorator := range c.keyDecorators(key) {
+		if res, err = decorator(res, c); err != nil {
+			return
+		}
+	}
+	c.emit(Event{Type: EventAfterInstantiate, Key: key, Elapsed: time.Since(start), Instance: res})
+	return
+}
+
+// Extend 注册装饰器
+func (c *GoWebContainer) Extend(key string, decorator Decorator) (err error) {
+	key = c.realKey(key)
+
+	// 和实例化使用同一把锁，避免包装已有单例的时候，有别的协程正在实例化
+	mu := c.creatingLock(key)
+	mu.Lock()
+	defer mu.Unlock()
+
+	c.lock.Lock()
+	c.decorators[key] = append(c.decorators[key], decorator)
+	instance, ok := c.instances[key]
+	c.lock.Unlock()
+	if !ok {
+		return
+	}
+
+	instance, err = decorator(instance, c)
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if err != nil {
+		// 包装失败就不注册这个装饰器，持有 mu 期间不会有别的装饰器注册进来，所以最后一个就是它
+		decorators := c.decorators[key]
+		c.decorators[key] = decorators[:len(decorators)-1]
+		return
+	}
+	c.instances[key] = instance
 	return
 }
+
+// keyDecorators 获取服务的所有装饰器，子容器中先是父容器的装饰器，再是自己注册的
+func (c *GoWebContainer) keyDecorators(key string) (decorators []Decorator) {
+	if c.parent != nil {
+		decorators = c.parent.keyDecorators(key)
+	}
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return append(decorators, c.decorators[key]...)
+}
+
+// Providers 获取所有绑定的服务提供者的运行时信息，子容器中包含父容器绑定的服务提供者
+func (c *GoWebContainer) Providers() []ProviderInfo {
+	infos := make(map[string]ProviderInfo)
+	if c.parent != nil {
+		for _, info := range c.parent.Providers() {
+			infos[info.Key] = info
+		}
+	}
+
+	c.lock.RLock()
+	for key, provider := range c.providers {
+		info := ProviderInfo{
+			Key:     key,
+			IsDefer: provider.IsDefer(),
+			Depends: providerDepends(provider),
+		}
+		if scoped, ok := provider.(ScopedProvider); ok {
+			info.IsScoped = scoped.IsScoped()
+		}
+		infos[key] = info
+	}
+	for _, key := range c.pending {
+		info := infos[key]
+		info.Pending = true
+		infos[key] = info
+	}
+	for key, instance := range c.instances {
+		info := infos[key]
+		info.Instantiated = true
+		info.InstanceType = fmt.Sprintf("%T", instance)
+		infos[key] = info
+	}
+	for tag, keys := range c.tags {
+		for _, key := range keys {
+			if info, ok := infos[key]; ok {
+				info.Tags = append(info.Tags, tag)
+				infos[key] = info
+			}
+		}
+	}
+	c.lock.RUnlock()
+
+	res := make([]ProviderInfo, 0, len(infos))
+	for _, info := range infos {
+		sort.Strings(info.Tags)
+		res = append(res, info)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Key < res[j].Key
+	})
+	return res
+}
+
+// SetShutdownTimeout 设置 Shutdown 时每个服务关闭的超时时间
+func (c *GoWebContainer) SetShutdownTimeout(timeout time.Duration) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.shutdownTimeout = timeout
+}
+
+// Shutdown 按照创建顺序的逆序关闭所有已经实例化的服务
+// 后创建的服务可能依赖先创建的服务（比如日志服务依赖配置服务），所以需要逆序关闭。
+// 子容器关闭之后再获取服务会返回错误，包括通过它创建的子容器
+// 单个服务关闭失败或者超时不会中断后续服务的关闭，所有的错误会聚合成 ShutdownError 返回
+func (c *GoWebContainer) Shutdown(ctx context.Context) error {
+	c.lock.Lock()
+	created := c.created
+	instances := c.instances
+	timeout := c.shutdownTimeout
+	// 关闭之后清空单例，根容器之后再获取服务会重新实例化，子容器则不再允许获取
+	c.created = nil
+	c.instances = make(map[string]interface{})
+	c.closed = c.parent != nil
+	c.lock.Unlock()
+
+	var errs ShutdownError
+	for i := len(created) - 1; i >= 0; i-- {
+		key := created[i]
+		if err := closeInstance(ctx, instances[key], timeout); err != nil {
+			errs = append(errs, fmt.Errorf("contract %s: %w", key, err))
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// closeInstance 调用实例的关闭钩子，超过 timeout 或者 ctx 结束就不再等待
+func closeInstance(ctx context.Context, instance interface{}, timeout time.Duration) error {
+	var closeFunc func(ctx context.Context) error
+	switch v := instance.(type) {
+	case Stopper:
+		closeFunc = v.Stop
+	case Closer:
+		closeFunc = func(context.Context) error {
+			return v.Close()
+		}
+	default:
+		return nil
+	}
+
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	// 关闭钩子可能阻塞，放在 goroutine 中执行，channel 带缓冲，超时之后 goroutine 也能正常退出
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if p := recover(); p != nil {
+				done <- fmt.Errorf("panic: %v", p)
+			}
+		}()
+		done <- closeFunc(ctx)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Fake 在测试中临时把 key 对应的服务替换成 instance，不会调用服务提供者的 Register、Boot，也不会经过装饰器。
+// 返回的 restore 用于恢复原来的服务提供者和实例，一般配合 defer 或者 t.Cleanup 使用：
+//
+//	t.Cleanup(container.Fake(config.Key, config.NewFakeService(nil)))
+func (c *GoWebContainer) Fake(key string, instance interface{}) (restore func()) {
+	key = c.realKey(key)
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	oldProvider, hasProvider := c.providers[key]
+	oldInstance, hasInstance := c.instances[key]
+	c.providers[key] = &fakeProvider{key: key, instance: instance}
+	c.instances[key] = instance
+
+	return func() {
+		c.lock.Lock()
+		defer c.lock.Unlock()
+		if hasProvider {
+			c.providers[key] = oldProvider
+		} else {
+			delete(c.providers, key)
+		}
+		if hasInstance {
+			c.instances[key] = oldInstance
+		} else {
+			delete(c.instances, key)
+		}
+	}
+}
+
+// fakeProvider 测试替身的服务提供者，MakeNew 的时候也返回同一个替身
+type fakeProvider struct {
+	key      string
+	instance interface{}
+}
+
+func (p *fakeProvider) Register(Container) NewInstance {
+	return func(...interface{}) (interface{}, error) {
+		return p.instance, nil
+	}
+}
+
+func (p *fakeProvider) Boot(Container) error {
+	return nil
+}
+
+func (p *fakeProvider) IsDefer() bool {
+	return true
+}
+
+func (p *fakeProvider) Params(Container) []interface{} {
+	return nil
+}
+
+func (p *fakeProvider) Name() string {
+	return p.key
+}
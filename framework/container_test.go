@@ -0,0 +1,535 @@
+package framework
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testProvider 测试用的服务提供者，实例化的时候记录顺序
+type testProvider struct {
+	name    string
+	depends []string
+	isDefer bool
+	created *[]string
+}
+
+func (p *testProvider) Register(Container) NewInstance {
+	return func(...interface{}) (interface{}, error) {
+		*p.created = append(*p.created, p.name)
+		return p.name, nil
+	}
+}
+
+func (p *testProvider) Boot(Container) error {
+	return nil
+}
+
+func (p *testProvider) IsDefer() bool {
+	return p.isDefer
+}
+
+func (p *testProvider) Params(Container) []interface{} {
+	return nil
+}
+
+func (p *testProvider) Name() string {
+	return p.name
+}
+
+func (p *testProvider) Depends() []string {
+	return p.depends
+}
+
+func TestGoWebContainer_BindOrder(t *testing.T) {
+	var created []string
+	c := NewGoWebContainer()
+
+	// 故意倒序绑定：log 依赖 config，config 依赖 app 和 env，env 依赖 app
+	binds := []*testProvider{
+		{name: "log", depends: []string{"app", "config"}},
+		{name: "config", depends: []string{"app", "env"}},
+		{name: "env", depends: []string{"app"}},
+		{name: "app"},
+	}
+	for _, p := range binds {
+		p.created = &created
+		if err := c.Bind(p); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if got := strings.Join(created, ","); got != "app,env,config,log" {
+		t.Fatalf("unexpected instantiation order: %s", got)
+	}
+}
+
+func TestGoWebContainer_DeferDepends(t *testing.T) {
+	var created []string
+	c := NewGoWebContainer()
+	_ = c.Bind(&testProvider{name: "a", depends: []string{"b"}, isDefer: true, created: &created})
+	_ = c.Bind(&testProvider{name: "b", isDefer: true, created: &created})
+	if len(created) != 0 {
+		t.Fatalf("defer provider should not be instantiated on bind: %v", created)
+	}
+
+	if res := c.MustMake("a"); res != "a" {
+		t.Fatalf("unexpected instance: %v", res)
+	}
+	if got := strings.Join(created, ","); got != "b,a" {
+		t.Fatalf("unexpected instantiation order: %s", got)
+	}
+}
+
+func TestGoWebContainer_Cycle(t *testing.T) {
+	var created []string
+	c := NewGoWebContainer()
+	if err := c.Bind(&testProvider{name: "a", depends: []string{"b"}, created: &created}); err != nil {
+		t.Fatal(err)
+	}
+	err := c.Bind(&testProvider{name: "b", depends: []string{"a"}, created: &created})
+	if err == nil || !strings.Contains(err.Error(), "a -> b -> a") {
+		t.Fatalf("expect circular dependency error, got: %v", err)
+	}
+	if len(created) != 0 {
+		t.Fatalf("nothing should be instantiated: %v", created)
+	}
+}
+
+func TestGoWebContainer_MissingDepend(t *testing.T) {
+	var created []string
+	c := NewGoWebContainer()
+	_ = c.Bind(&testProvider{name: "a", depends: []string{"b"}, isDefer: true, created: &created})
+
+	_, err := c.Make("a")
+	if err == nil || !strings.Contains(err.Error(), "depends on b") {
+		t.Fatalf("expect missing dependency error, got: %v", err)
+	}
+}
+
+// closeService 测试用的服务实例，关闭的时候记录顺序
+type closeService struct {
+	name   string
+	closed *[]string
+	err    error
+	block  bool
+}
+
+func (s *closeService) Close() error {
+	if s.block {
+		select {}
+	}
+	*s.closed = append(*s.closed, s.name)
+	return s.err
+}
+
+// closeProvider 返回 closeService 的服务提供者
+type closeProvider struct {
+	testProvider
+	service *closeService
+}
+
+func (p *closeProvider) Register(Container) NewInstance {
+	return func(...interface{}) (interface{}, error) {
+		return p.service, nil
+	}
+}
+
+func TestGoWebContainer_Shutdown(t *testing.T) {
+	var closed []string
+	c := NewGoWebContainer()
+	c.SetShutdownTimeout(50 * time.Millisecond)
+
+	services := []*closeService{
+		{name: "app", closed: &closed},
+		{name: "config", closed: &closed, err: errors.New("close config error")},
+		{name: "log", closed: &closed},
+		{name: "blocked", closed: &closed, block: true},
+	}
+	depends := map[string][]string{"config": {"app"}, "log": {"config"}}
+	// 倒序绑定，实例化顺序由依赖决定
+	for i := len(services) - 1; i >= 0; i-- {
+		s := services[i]
+		p := &closeProvider{testProvider: testProvider{name: s.name, depends: depends[s.name]}, service: s}
+		if err := c.Bind(p); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	err := c.Shutdown(context.Background())
+	if got := strings.Join(closed, ","); got != "log,config,app" {
+		t.Fatalf("unexpected close order: %s", got)
+	}
+	errs, ok := err.(ShutdownError)
+	if !ok || len(errs) != 2 {
+		t.Fatalf("expect two aggregated errors, got: %v", err)
+	}
+	// blocked 最先绑定也最先实例化，所以最后关闭
+	if !strings.Contains(errs[0].Error(), "close config error") || !errors.Is(errs[1], context.DeadlineExceeded) {
+		t.Fatalf("unexpected errors: %v", err)
+	}
+}
+
+// scopedProvider 测试用的作用域服务提供者
+type scopedProvider struct {
+	testProvider
+	closed *[]string
+}
+
+func (p *scopedProvider) Register(Container) NewInstance {
+	return func(...interface{}) (interface{}, error) {
+		*p.created = append(*p.created, p.name)
+		return &closeService{name: p.name, closed: p.closed}, nil
+	}
+}
+
+func (p *scopedProvider) IsScoped() bool {
+	return true
+}
+
+func TestGoWebContainer_NewScope(t *testing.T) {
+	var created, closed []string
+	root := NewGoWebContainer()
+	_ = root.Bind(&testProvider{name: "app", created: &created})
+	_ = root.Bind(&scopedProvider{
+		testProvider: testProvider{name: "tx", depends: []string{"app"}, isDefer: true, created: &created},
+		closed:       &closed,
+	})
+
+	first := root.NewScope()
+	if first.MustMake("tx") != first.MustMake("tx") {
+		t.Fatal("scoped instance should be cached in one scope")
+	}
+	second := root.NewScope()
+	if first.MustMake("tx") == second.MustMake("tx") {
+		t.Fatal("scoped instance should not be shared between scopes")
+	}
+	if first.MustMake("app") != root.MustMake("app") {
+		t.Fatal("singleton should be shared with root")
+	}
+
+	// 子容器关闭只释放自己的作用域服务，singleton 不受影响
+	if err := first.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(created, ","); got != "app,tx,tx" {
+		t.Fatalf("unexpected instantiation records: %s", got)
+	}
+	if got := strings.Join(closed, ","); got != "tx" {
+		t.Fatalf("unexpected close records: %s", got)
+	}
+	if !root.IsBind("tx") || !first.IsBind("tx") {
+		t.Fatal("scope should inherit bindings from root")
+	}
+
+	// 关闭之后的子容器不能再实例化服务
+	if _, err := first.Make("tx"); err == nil || !strings.Contains(err.Error(), "scope is shut down") {
+		t.Fatalf("expect shut down error, got: %v", err)
+	}
+	// 它的子容器不能再获取它绑定的服务，全局服务交给更上层的容器
+	_ = second.Bind(&testProvider{name: "request", isDefer: true, created: &created})
+	child := second.NewScope()
+	_ = second.Shutdown(context.Background())
+	if _, err := child.Make("request"); err == nil || !strings.Contains(err.Error(), "scope is shut down") {
+		t.Fatalf("expect shut down error, got: %v", err)
+	}
+	if child.MustMake("app") != root.MustMake("app") {
+		t.Fatal("child of a shut down scope should still share singletons with root")
+	}
+	if got := strings.Join(created, ","); got != "app,tx,tx" {
+		t.Fatalf("nothing should be instantiated after shutdown: %s", got)
+	}
+}
+
+// taggedProvider 测试用的带标签的服务提供者
+type taggedProvider struct {
+	testProvider
+	tags []string
+}
+
+func (p *taggedProvider) Tags() []string {
+	return p.tags
+}
+
+func TestGoWebContainer_ResolveAll(t *testing.T) {
+	var created []string
+	c := NewGoWebContainer()
+	_ = c.Bind(&taggedProvider{testProvider{name: "checker.db", isDefer: true, created: &created}, []string{"health"}})
+	_ = c.Bind(&taggedProvider{testProvider{name: "checker.config", created: &created}, []string{"health"}})
+	_ = c.Bind(&testProvider{name: "checker.log", isDefer: true, created: &created})
+	c.Tag("health", "checker.log")
+	// 重复绑定保持第一次的位置
+	_ = c.Bind(&taggedProvider{testProvider{name: "checker.db", isDefer: true, created: &created}, []string{"health"}})
+
+	res, err := c.ResolveAll("health")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res) != 3 || res[0] != "checker.db" || res[1] != "checker.config" || res[2] != "checker.log" {
+		t.Fatalf("unexpected tagged services: %v", res)
+	}
+
+	scope := c.NewScope()
+	_ = scope.Bind(&taggedProvider{testProvider{name: "checker.request", isDefer: true, created: &created}, []string{"health"}})
+	if res, _ = scope.ResolveAll("health"); len(res) != 4 || res[3] != "checker.request" {
+		t.Fatalf("unexpected tagged services in scope: %v", res)
+	}
+	if res, _ = c.ResolveAll("unknown"); len(res) != 0 {
+		t.Fatalf("unknown tag should resolve nothing: %v", res)
+	}
+}
+
+func TestGoWebContainer_Alias(t *testing.T) {
+	var created []string
+	c := NewGoWebContainer()
+	_ = c.Bind(&testProvider{name: "goweb:log", isDefer: true, created: &created})
+
+	if err := c.Alias("logger", "goweb:log"); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Alias("log", "logger"); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Alias("goweb:log", "log"); err == nil {
+		t.Fatal("alias should not shadow a bound contract")
+	}
+	if err := c.Alias("logger", "log"); err == nil {
+		t.Fatal("circular alias should fail")
+	}
+
+	if !c.IsBind("log") || c.MustMake("log") != c.MustMake("goweb:log") {
+		t.Fatal("alias should resolve to the same instance")
+	}
+	if len(created) != 1 {
+		t.Fatalf("alias should not instantiate twice: %v", created)
+	}
+	if c.NewScope().MustMake("logger") != "goweb:log" {
+		t.Fatal("scope should resolve aliases of root")
+	}
+}
+
+func TestGoWebContainer_Extend(t *testing.T) {
+	var created []string
+	c := NewGoWebContainer()
+	wrap := func(prefix string) Decorator {
+		return func(instance interface{}, container Container) (interface{}, error) {
+			return prefix + "(" + instance.(string) + ")", nil
+		}
+	}
+
+	// 先绑定后装饰：已经实例化的单例立即被包装
+	_ = c.Bind(&testProvider{name: "log", created: &created})
+	if err := c.Extend("log", wrap("redact")); err != nil {
+		t.Fatal(err)
+	}
+	if res := c.MustMake("log"); res != "redact(log)" {
+		t.Fatalf("unexpected decorated instance: %v", res)
+	}
+
+	// 先装饰后绑定：延迟实例化和 MakeNew 都会按照注册顺序包装
+	_ = c.Extend("lock", wrap("metrics"))
+	_ = c.Extend("lock", wrap("trace"))
+	_ = c.Bind(&testProvider{name: "lock", isDefer: true, created: &created})
+	if res := c.MustMake("lock"); res != "trace(metrics(lock))" {
+		t.Fatalf("unexpected decorated instance: %v", res)
+	}
+	if res, _ := c.MakeNew("lock", nil); res != "trace(metrics(lock))" {
+		t.Fatalf("unexpected decorated new instance: %v", res)
+	}
+
+	fail := func(interface{}, Container) (interface{}, error) {
+		return nil, errors.New("decorate error")
+	}
+	if err := c.Extend("log", fail); err == nil {
+		t.Fatal("decorator error should be returned")
+	}
+	if res, err := c.MakeNew("log", nil); err != nil || res != "redact(log)" {
+		t.Fatalf("failed decorator should not be registered: %v, %v", res, err)
+	}
+}
+
+func TestGoWebContainer_Fake(t *testing.T) {
+	var created []string
+	c := NewGoWebContainer()
+	_ = c.Bind(&testProvider{name: "goweb:config", created: &created})
+	_ = c.Extend("goweb:config", func(instance interface{}, container Container) (interface{}, error) {
+		return "wrapped", nil
+	})
+
+	restore := c.Fake("goweb:config", "fake config")
+	restoreEnv := c.Fake("goweb:env", "fake env")
+	if c.MustMake("goweb:config") != "fake config" || c.NewScope().MustMake("goweb:env") != "fake env" {
+		t.Fatal("fake instance should be resolved")
+	}
+	if res, _ := c.MakeNew("goweb:config", nil); res != "fake config" {
+		t.Fatalf("fake should bypass Register and decorators: %v", res)
+	}
+
+	restore()
+	restoreEnv()
+	if c.MustMake("goweb:config") != "wrapped" {
+		t.Fatal("original instance should be restored")
+	}
+	if c.IsBind("goweb:env") {
+		t.Fatal("fake of an unbound key should be removed on restore")
+	}
+	if len(created) != 1 {
+		t.Fatalf("fake should not instantiate the original provider: %v", created)
+	}
+}
+
+func TestGoWebContainer_Providers(t *testing.T) {
+	var created []string
+	c := NewGoWebContainer()
+	_ = c.Bind(&testProvider{name: "b", created: &created})
+	_ = c.Bind(&testProvider{name: "a", depends: []string{"b"}, isDefer: true, created: &created})
+
+	infos := c.Providers()
+	if len(infos) != 2 || infos[0].Key != "a" || infos[1].Key != "b" {
+		t.Fatalf("providers should be sorted by key: %+v", infos)
+	}
+	if !infos[0].IsDefer || infos[0].Instantiated || len(infos[0].Depends) != 1 {
+		t.Fatalf("unexpected info of a: %+v", infos[0])
+	}
+	if infos[1].IsDefer || !infos[1].Instantiated || infos[1].InstanceType != "string" {
+		t.Fatalf("unexpected info of b: %+v", infos[1])
+	}
+}
+
+// failProvider Boot 失败的服务提供者
+type failProvider struct {
+	testProvider
+}
+
+func (p *failProvider) Boot(Container) error {
+	return errors.New("boot error")
+}
+
+func TestGoWebContainer_AddHook(t *testing.T) {
+	var created []string
+	c := NewGoWebContainer()
+	_ = c.Bind(&testProvider{name: "app", created: &created})
+
+	var events []string
+	c.AddHook(func(event Event) {
+		if event.Type == EventAfterInstantiate && event.Instance == nil && event.Key == "config" {
+			t.Error("after_instantiate should carry the instance")
+		}
+		if event.Type == EventError && event.Err == nil {
+			t.Error("error event should carry the error")
+		}
+		events = append(events, string(event.Type)+":"+event.Key)
+	})
+	_ = c.Bind(&testProvider{name: "config", depends: []string{"app"}, created: &created})
+	_ = c.Bind(&failProvider{testProvider{name: "db", isDefer: true, created: &created}})
+	if _, err := c.Make("db"); err == nil {
+		t.Fatal("boot error should be returned")
+	}
+
+	// 添加钩子之前 app 的事件会被回放
+	expect := []string{
+		"bind:app", "before_boot:app", "after_boot:app", "after_instantiate:app",
+		"bind:config", "before_boot:config", "after_boot:config", "after_instantiate:config",
+		"bind:db", "before_boot:db", "error:db",
+	}
+	if got := strings.Join(events, ","); got != strings.Join(expect, ",") {
+		t.Fatalf("unexpected events: %s", got)
+	}
+}
+
+func TestGoWebContainer_Rebind(t *testing.T) {
+	var closed []string
+	c := NewGoWebContainer()
+	old := &closeService{name: "old", closed: &closed}
+	if err := c.Bind(&closeProvider{testProvider: testProvider{name: "svc"}, service: old}); err != nil {
+		t.Fatal(err)
+	}
+	if c.MustMake("svc") != old {
+		t.Fatal("expect old instance")
+	}
+
+	// 重新绑定之后获取的是新的实例，旧的实例被关闭
+	replaced := &closeService{name: "new", closed: &closed}
+	if err := c.Bind(&closeProvider{testProvider: testProvider{name: "svc"}, service: replaced}); err != nil {
+		t.Fatal(err)
+	}
+	if c.MustMake("svc") != replaced {
+		t.Fatal("expect new instance after rebind")
+	}
+	if strings.Join(closed, ",") != "old" {
+		t.Fatalf("old instance should be closed once: %v", closed)
+	}
+	_ = c.Shutdown(context.Background())
+	if strings.Join(closed, ",") != "old,new" {
+		t.Fatalf("unexpected closed instances: %v", closed)
+	}
+}
+
+func TestGoWebContainer_Verify(t *testing.T) {
+	var created []string
+	c := NewGoWebContainer()
+	if err := c.Bind(&testProvider{name: "a", depends: []string{"b"}, created: &created}); err != nil {
+		t.Fatal(err)
+	}
+	err := c.Verify()
+	if err == nil || !strings.Contains(err.Error(), "contract a depends on b, which have not register") {
+		t.Fatalf("expect pending error, got: %v", err)
+	}
+	for _, info := range c.Providers() {
+		if info.Key == "a" && !info.Pending {
+			t.Fatal("a should be pending")
+		}
+	}
+
+	if err = c.Bind(&testProvider{name: "b", created: &created}); err != nil {
+		t.Fatal(err)
+	}
+	if err = c.Verify(); err != nil {
+		t.Fatalf("nothing should be pending: %v", err)
+	}
+}
+
+func TestGoWebContainer_BindDeferDepend(t *testing.T) {
+	var created []string
+	c := NewGoWebContainer()
+	if err := c.Bind(&testProvider{name: "a", depends: []string{"b"}, created: &created}); err != nil {
+		t.Fatal(err)
+	}
+	// 延迟的依赖绑定之后，等待的服务也要实例化
+	if err := c.Bind(&testProvider{name: "b", isDefer: true, created: &created}); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(created, ",") != "b,a" {
+		t.Fatalf("unexpected created order: %v", created)
+	}
+	if err := c.Verify(); err != nil {
+		t.Fatalf("nothing should be pending: %v", err)
+	}
+
+	// 延迟的服务绑定之后形成的环，Bind 就要报错
+	c = NewGoWebContainer()
+	if err := c.Bind(&testProvider{name: "a", depends: []string{"b"}, created: &created}); err != nil {
+		t.Fatal(err)
+	}
+	err := c.Bind(&testProvider{name: "b", depends: []string{"a"}, isDefer: true, created: &created})
+	if err == nil || !strings.Contains(err.Error(), "circular dependency") {
+		t.Fatalf("expect circular error, got: %v", err)
+	}
+	if err = c.Verify(); err == nil || !strings.Contains(err.Error(), "circular dependency") {
+		t.Fatalf("expect circular error, got: %v", err)
+	}
+}
+
+func TestGoWebContainer_VerifyNotInstantiated(t *testing.T) {
+	c := NewGoWebContainer()
+	err := c.Bind(&failProvider{testProvider: testProvider{name: "a"}})
+	if err == nil {
+		t.Fatal("expect instantiate error")
+	}
+	// 依赖都绑定了，但是实例化失败的服务也要报告
+	if err = c.Verify(); err == nil || !strings.Contains(err.Error(), "contract a is not instantiated") {
+		t.Fatalf("expect not instantiated error, got: %v", err)
+	}
+}
@@ -0,0 +1,72 @@
+package framework
+
+import "time"
+
+// EventType 容器事件的类型
+type EventType string
+
+const (
+	// EventBind 绑定服务提供者
+	EventBind EventType = "bind"
+	// EventBeforeBoot 调用服务提供者的 Boot 之前
+	EventBeforeBoot EventType = "before_boot"
+	// EventAfterBoot 调用服务提供者的 Boot 之后，Elapsed 为 Boot 的耗时
+	EventAfterBoot EventType = "after_boot"
+	// EventAfterInstantiate 服务实例化（包括装饰器）完成之后，Elapsed 为从 Boot 开始的总耗时
+	EventAfterInstantiate EventType = "after_instantiate"
+	// EventError 依赖解析、Boot、实例化或者装饰器出错
+	EventError EventType = "error"
+)
+
+// maxEventHistory 根容器最多保留的历史事件个数，避免常驻进程中内存一直增长
+const maxEventHistory = 512
+
+// Event 容器事件，用于定位启动慢是哪个服务的 Boot 或者 NewInstance 导致的
+type Event struct {
+	Type     EventType
+	Key      string        // 服务凭证
+	Elapsed  time.Duration // 耗时，EventBind 和 EventBeforeBoot 为 0
+	Err      error         // EventError 的错误信息
+	Instance interface{}   // EventAfterInstantiate 实例化出来的服务
+}
+
+// Hook 容器事件的钩子，同步调用，钩子中不要通过容器实例化当前事件对应的服务，否则会死锁
+type Hook func(event Event)
+
+// AddHook 添加事件钩子，并且回放根容器中已经发生的事件，这样在服务 Boot 中添加的钩子也能拿到启动阶段的耗时
+func (c *GoWebContainer) AddHook(hook Hook) {
+	c.lock.Lock()
+	c.hooks = append(c.hooks, hook)
+	history := append([]Event{}, c.history...)
+	c.lock.Unlock()
+
+	for _, event := range history {
+		hook(event)
+	}
+}
+
+// emit 触发事件，子容器的事件同样会通知父容器的钩子
+func (c *GoWebContainer) emit(event Event) {
+	c.lock.Lock()
+	if c.parent == nil && len(c.history) < maxEventHistory {
+		// 历史事件不保留实例，避免 MakeNew 出来的实例一直无法回收
+		history := event
+		history.Instance = nil
+		c.history = append(c.history, history)
+	}
+	c.lock.Unlock()
+
+	for _, hook := range c.allHooks() {
+		hook(event)
+	}
+}
+
+// allHooks 获取当前容器和所有父容器的钩子
+func (c *GoWebContainer) allHooks() (hooks []Hook) {
+	if c.parent != nil {
+		hooks = c.parent.allHooks()
+	}
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return append(hooks, c.hooks...)
+}
@@ -57,7 +57,10 @@ type Context struct {
 	fullPath string
 
 	// 容器
-	container    framework.Container
+	container framework.Container
+	// scope 当前请求的子容器，第一次获取服务的时候创建，请求结束的时候释放
+	scope framework.Container
+
 	engine       *Engine
 	params       *Params
 	skippedNodes *[]skippedNode
@@ -114,6 +117,11 @@ func (c *Context) Copy() *Context {
 		Request:   c.Request,
 		Params:    c.Params,
 		engine:    c.engine,
+		container: c.container,
+	}
+	// 复制出来的 Context 使用请求子容器下的子容器，作用域服务单独实例化，使用完之后需要调用 Release 释放
+	if c.container != nil {
+		cp.scope = c.Scope().NewScope()
 	}
 	cp.writermem.ResponseWriter = nil
 	cp.Writer = &cp.writermem
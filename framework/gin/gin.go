@@ -159,6 +159,8 @@ type Engine struct {
 
 	// 容器
 	container framework.Container
+	// injects 登记的需要注入服务的控制器
+	injects []interface{}
 }
 
 var _ IRouter = &Engine{}
@@ -562,6 +564,9 @@ func (engine *Engine) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 
 	engine.handleHTTPRequest(c)
 
+	// 请求结束，释放请求级别的子容器
+	c.releaseScope()
+
 	engine.pool.Put(c)
 }
 
@@ -19,16 +19,64 @@ func (engine *Engine) SetContainer(container framework.Container) {
 	engine.container = container
 }
 
+// Inject 登记需要注入服务的控制器，target 是结构体指针。
+// 注册路由的时候 Web 引擎的容器可能还没有设置，所以先登记，等 kernel 服务设置容器之后通过 ResolveInjects 统一注入
+func (engine *Engine) Inject(target interface{}) {
+	engine.injects = append(engine.injects, target)
+}
+
+// ResolveInjects 使用当前的容器填充所有登记过的控制器，服务没有绑定或者类型不匹配会返回错误
+func (engine *Engine) ResolveInjects() error {
+	for _, target := range engine.injects {
+		if err := framework.Inject(engine.container, target); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Scope 获取当前请求的子容器，不存在就创建。
+// 可以在中间件中往子容器绑定只属于这个请求的服务，比如带着 trace_id 的日志服务
+func (c *Context) Scope() framework.Container {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.scope == nil {
+		c.scope = c.container.NewScope()
+	}
+	return c.scope
+}
+
+// releaseScope 请求结束的时候释放子容器中的作用域服务
+func (c *Context) releaseScope() {
+	c.mu.Lock()
+	scope := c.scope
+	c.scope = nil
+	c.mu.Unlock()
+	if scope == nil {
+		return
+	}
+	if err := scope.Shutdown(context.Background()); err != nil {
+		debugPrint("[WARNING] release request scope error: %v\n", err)
+	}
+}
+
+// Release 释放 Copy 出来的 Context 的子容器，在 goroutine 中使用完之后调用。
+// 原请求结束之后，通过请求子容器绑定的服务不能再获取，只有全局服务和作用域服务可以继续使用
+func (c *Context) Release() {
+	c.releaseScope()
+}
+
+// Make 通过请求的子容器获取服务，作用域服务在一个请求内只会实例化一次
 func (c *Context) Make(key string) (interface{}, error) {
-	return c.container.Make(key)
+	return c.Scope().Make(key)
 }
 
 func (c *Context) MustMake(key string) interface{} {
-	return c.container.MustMake(key)
+	return c.Scope().MustMake(key)
 }
 
 func (c *Context) MakeNew(key string, params []interface{}) (interface{}, error) {
-	return c.container.MakeNew(key, params)
+	return c.Scope().MakeNew(key, params)
 }
 
 func (c *Context) BaseContext() context.Context {
@@ -0,0 +1,108 @@
+package gin
+
+import (
+	"github.com/wxsatellite/goweb/framework"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// scopedCounter 请求作用域的测试服务，记录实例化和关闭的次数
+type scopedCounter struct {
+	created *int
+	closed  *int
+}
+
+func (p *scopedCounter) Register(framework.Container) framework.NewInstance {
+	return func(...interface{}) (interface{}, error) {
+		*p.created++
+		return &closeCounter{closed: p.closed}, nil
+	}
+}
+
+func (p *scopedCounter) Boot(framework.Container) error {
+	return nil
+}
+
+func (p *scopedCounter) IsDefer() bool {
+	return true
+}
+
+func (p *scopedCounter) Params(framework.Container) []interface{} {
+	return nil
+}
+
+func (p *scopedCounter) Name() string {
+	return "test:scoped"
+}
+
+func (p *scopedCounter) IsScoped() bool {
+	return true
+}
+
+// globalService 全局的测试服务，所有的子容器共用一个实例
+type globalService struct{}
+
+func (p *globalService) Register(framework.Container) framework.NewInstance {
+	return func(...interface{}) (interface{}, error) {
+		return p, nil
+	}
+}
+
+func (p *globalService) Boot(framework.Container) error {
+	return nil
+}
+
+func (p *globalService) IsDefer() bool {
+	return true
+}
+
+func (p *globalService) Params(framework.Container) []interface{} {
+	return nil
+}
+
+func (p *globalService) Name() string {
+	return "test:global"
+}
+
+type closeCounter struct {
+	closed *int
+}
+
+func (s *closeCounter) Close() error {
+	*s.closed++
+	return nil
+}
+
+func TestContextCopyScope(t *testing.T) {
+	var created, closed int
+	container := framework.NewGoWebContainer()
+	_ = container.Bind(&scopedCounter{created: &created, closed: &closed})
+	global := &globalService{}
+	_ = container.Bind(global)
+
+	engine := New()
+	engine.SetContainer(container)
+	var cp *Context
+	engine.GET("/", func(c *Context) {
+		c.MustMake("test:scoped")
+		cp = c.Copy()
+	})
+	engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	if created != 1 || closed != 1 {
+		t.Fatalf("request scope should be released: created %d, closed %d", created, closed)
+	}
+
+	// 请求结束之后复制出来的 Context 依然可以获取作用域服务，Release 的时候关闭
+	if _, err := cp.Make("test:scoped"); err != nil {
+		t.Fatal(err)
+	}
+	// 全局服务也可以继续获取，并且是容器中的单例
+	if instance, err := cp.Make("test:global"); err != nil || instance != global {
+		t.Fatalf("expect global service after request, got %v, %v", instance, err)
+	}
+	cp.Release()
+	if created != 2 || closed != 2 {
+		t.Fatalf("copied scope should be released: created %d, closed %d", created, closed)
+	}
+}
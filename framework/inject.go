@@ -0,0 +1,75 @@
+package framework
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// InjectTag 结构体字段上用于声明服务凭证的标签，例如：
+//
+//	type UserController struct {
+//		Log    log.Log       `inject:"goweb:log"`
+//		Config config.Config `inject:"goweb:config"`
+//	}
+const InjectTag = "inject"
+
+// Inject 从容器中获取服务，填充 target 结构体中带有 inject 标签的字段，target 必须是结构体指针。
+// 需要在注册控制器、命令的时候调用，这样服务没有绑定或者类型和字段不匹配的问题在启动时就能发现，而不是等到请求的时候才 panic。
+// 注入的是单例，作用域服务需要在请求中通过 Context.Make 获取
+func Inject(container Container, target interface{}) error {
+	v := reflect.ValueOf(target)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return errors.New("inject target must be a non-nil struct pointer, got " + fmt.Sprintf("%T", target))
+	}
+	v = v.Elem()
+	t := v.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		key, ok := field.Tag.Lookup(InjectTag)
+		if !ok {
+			continue
+		}
+		if key == "" {
+			return errors.New("inject " + t.Name() + "." + field.Name + ": empty contract key")
+		}
+		if field.PkgPath != "" {
+			return errors.New("inject " + t.Name() + "." + field.Name + ": field must be exported")
+		}
+		if !container.IsBind(key) {
+			return errors.New("inject " + t.Name() + "." + field.Name + ": contract " + key + " have not register")
+		}
+		instance, err := container.Make(key)
+		if err != nil {
+			return fmt.Errorf("inject %s.%s: %w", t.Name(), field.Name, err)
+		}
+		if instance == nil {
+			return errors.New("inject " + t.Name() + "." + field.Name + ": contract " + key + " made a nil instance")
+		}
+		iv := reflect.ValueOf(instance)
+		if !iv.Type().AssignableTo(field.Type) {
+			return errors.New("inject " + t.Name() + "." + field.Name + ": contract " + key + " is " + iv.Type().String() +
+				", which can not be assigned to " + field.Type.String())
+		}
+		v.Field(i).Set(iv)
+	}
+	return nil
+}
+
+// InjectKeys 获取 target 结构体中 inject 标签声明的所有服务凭证，可以用来声明服务提供者的依赖
+func InjectKeys(target interface{}) (keys []string) {
+	t := reflect.TypeOf(target)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return
+	}
+	for i := 0; i < t.NumField(); i++ {
+		if key, ok := t.Field(i).Tag.Lookup(InjectTag); ok && key != "" {
+			keys = append(keys, key)
+		}
+	}
+	return
+}
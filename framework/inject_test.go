@@ -0,0 +1,56 @@
+package framework
+
+import (
+	"strings"
+	"testing"
+)
+
+// namer 测试注入接口类型的字段
+type namer interface {
+	Name() string
+}
+
+type namerService struct{}
+
+func (namerService) Name() string {
+	return "namer"
+}
+
+func TestInject(t *testing.T) {
+	c := NewGoWebContainer()
+	c.Fake("goweb:namer", namerService{})
+	c.Fake("goweb:count", 1)
+
+	var controller struct {
+		Namer namer `inject:"goweb:namer"`
+		Count int   `inject:"goweb:count"`
+		Other string
+	}
+	if err := Inject(c, &controller); err != nil {
+		t.Fatal(err)
+	}
+	if controller.Namer.Name() != "namer" || controller.Count != 1 {
+		t.Fatalf("unexpected injected controller: %+v", controller)
+	}
+	if keys := InjectKeys(&controller); strings.Join(keys, ",") != "goweb:namer,goweb:count" {
+		t.Fatalf("unexpected inject keys: %v", keys)
+	}
+
+	var unbound struct {
+		Log namer `inject:"goweb:log"`
+	}
+	if err := Inject(c, &unbound); err == nil || !strings.Contains(err.Error(), "have not register") {
+		t.Fatalf("expect unbound error, got: %v", err)
+	}
+
+	var mismatch struct {
+		Namer namer `inject:"goweb:count"`
+	}
+	if err := Inject(c, &mismatch); err == nil || !strings.Contains(err.Error(), "can not be assigned") {
+		t.Fatalf("expect type mismatch error, got: %v", err)
+	}
+
+	if err := Inject(c, controller); err == nil {
+		t.Fatal("non-pointer target should fail")
+	}
+}
@@ -22,3 +22,24 @@ type ServiceProvider interface {
 	// Name 代表了这个服务提供者的凭证
 	Name() string
 }
+
+// DependentProvider 服务提供者可以选择实现这个接口，声明自己依赖的其他服务凭证。
+// 容器会保证依赖的服务先于自己实例化，非延迟的服务提供者在依赖没有全部绑定之前会一直等待，
+// 这样 Bind 的调用顺序就不再重要了
+type DependentProvider interface {
+	// Depends 返回依赖的服务凭证
+	Depends() []string
+}
+
+// ScopedProvider 服务提供者可以选择实现这个接口，IsScoped 返回 true 表示这是一个作用域服务：
+// 在每个子容器（比如一个 HTTP 请求）中只实例化一次，子容器 Shutdown 的时候释放。
+// 在根容器中获取作用域服务等同于获取单例
+type ScopedProvider interface {
+	IsScoped() bool
+}
+
+// TaggedProvider 服务提供者可以选择实现这个接口，绑定的时候会被加入到 Tags 返回的每个标签中，
+// 之后可以通过容器的 ResolveAll 按照绑定顺序一次性获取同一个标签下的所有服务，比如多个健康检查、多个日志输出
+type TaggedProvider interface {
+	Tags() []string
+}
@@ -0,0 +1,56 @@
+package app
+
+import (
+	"runtime"
+	"runtime/debug"
+)
+
+// 构建信息在编译的时候通过 -ldflags 注入，例如：
+// go build -ldflags "-X github.com/wxsatellite/goweb/framework/provider/app.version=v1.2.0 -X github.com/wxsatellite/goweb/framework/provider/app.gitCommit=$(git rev-parse HEAD) -X github.com/wxsatellite/goweb/framework/provider/app.buildTime=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+// 没有注入版本的时候，使用 go install 时模块的版本，都没有的时候为 DefaultVersion
+// 没有注入提交和构建时间的时候，使用 go build 写入的版本控制信息 vcs.revision 和 vcs.time，这时构建时间为提交的时间
+var (
+	version   string
+	gitCommit string
+	buildTime string
+)
+
+// DefaultVersion 没有注入版本号时的版本
+const DefaultVersion = "dev"
+
+// BuildInfo 应用的构建信息
+type BuildInfo struct {
+	Version   string `json:"version"`
+	GitCommit string `json:"git_commit"`
+	BuildTime string `json:"build_time"`
+	GoVersion string `json:"go_version"`
+}
+
+// GetBuildInfo 获取当前程序的构建信息
+func GetBuildInfo() BuildInfo {
+	info := BuildInfo{
+		Version:   version,
+		GitCommit: gitCommit,
+		BuildTime: buildTime,
+		GoVersion: runtime.Version(),
+	}
+	if info.Version == "" {
+		// go build 编译的时候模块版本为 (devel)，只有 go install module@version 的时候才有意义
+		if bi, ok := debug.ReadBuildInfo(); ok && bi.Main.Version != "" && bi.Main.Version != "(devel)" {
+			info.Version = bi.Main.Version
+		}
+	}
+	if info.Version == "" {
+		info.Version = DefaultVersion
+	}
+	if info.GitCommit == "" || info.BuildTime == "" {
+		revision, time := vcsInfo()
+		if info.GitCommit == "" {
+			info.GitCommit = revision
+		}
+		if info.BuildTime == "" {
+			info.BuildTime = time
+		}
+	}
+	return info
+}
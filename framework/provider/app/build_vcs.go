@@ -0,0 +1,30 @@
+//go:build go1.18
+// +build go1.18
+
+package app
+
+import "runtime/debug"
+
+// vcsInfo go 1.18 开始 go build 会把版本控制的信息写入程序，返回提交和提交的时间，
+// 有没有提交的修改的时候提交后面加上 -dirty
+func vcsInfo() (revision string, time string) {
+	bi, ok := debug.ReadBuildInfo()
+	if !ok {
+		return
+	}
+	modified := false
+	for _, setting := range bi.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			revision = setting.Value
+		case "vcs.time":
+			time = setting.Value
+		case "vcs.modified":
+			modified = setting.Value == "true"
+		}
+	}
+	if revision != "" && modified {
+		revision += "-dirty"
+	}
+	return
+}
@@ -0,0 +1,9 @@
+//go:build !go1.18
+// +build !go1.18
+
+package app
+
+// vcsInfo go 1.18 之前 go build 不会写入版本控制的信息
+func vcsInfo() (revision string, time string) {
+	return
+}
@@ -7,8 +7,10 @@ const Key = "goweb:app"
 // App 定义接口
 // 根据 App 的接口定义我们可以得出一个业务目录应该需要如下目录：配置文件、日志、服务提供者与实现、中间件、命令行、运行时产生的信息、测试等目录。
 type App interface {
-	// Version 定义当前版本
+	// Version 定义当前版本，编译时注入，参考 BuildInfo
 	Version() string
+	// BuildInfo 获取版本、git commit、构建时间以及 Go 版本
+	BuildInfo() BuildInfo
 	//BaseFolder 定义项目基础地址
 	BaseFolder() string
 	// ConfigFolder 定义了配置文件的路径
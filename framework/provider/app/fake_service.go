@@ -0,0 +1,42 @@
+package app
+
+// FakeService 用于测试的目录服务，所有目录都基于传入的基础路径，不会解析命令行参数
+type FakeService struct {
+	Service
+
+	version string
+}
+
+var _ App = (*FakeService)(nil)
+
+// NewFakeService 创建测试用的目录服务，baseFolder 一般传入 t.TempDir()
+func NewFakeService(baseFolder string) *FakeService {
+	return &FakeService{
+		Service: Service{
+			baseFolder: baseFolder,
+			appId:      "fake-app-id",
+			configMap:  make(map[string]string),
+		},
+		version: "0.0.0-fake",
+	}
+}
+
+// SetVersion 设置版本号
+func (s *FakeService) SetVersion(version string) {
+	s.version = version
+}
+
+// SetAppId 设置应用的唯一id，用于模拟多个节点抢占分布式锁
+func (s *FakeService) SetAppId(appId string) {
+	s.appId = appId
+}
+
+func (s *FakeService) Version() string {
+	return s.version
+}
+
+func (s *FakeService) BuildInfo() BuildInfo {
+	info := GetBuildInfo()
+	info.Version = s.version
+	return info
+}
@@ -0,0 +1,23 @@
+package app
+
+import (
+	"github.com/wxsatellite/goweb/framework/gin"
+	"net/http"
+)
+
+// RegisterRoutes 在 Web 引擎上挂载 /version，用于确认线上运行的是哪一次构建
+func RegisterRoutes(engine *gin.Engine) {
+	engine.GET("/version", VersionHandler())
+}
+
+// VersionHandler 以 json 格式返回应用的构建信息，没有绑定应用服务的时候返回程序本身的构建信息
+func VersionHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		service, err := c.Make(Key)
+		if err != nil {
+			c.JSON(http.StatusOK, GetBuildInfo())
+			return
+		}
+		c.JSON(http.StatusOK, service.(App).BuildInfo())
+	}
+}
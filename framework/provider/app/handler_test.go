@@ -0,0 +1,34 @@
+package app
+
+import (
+	"encoding/json"
+	"github.com/wxsatellite/goweb/framework"
+	"github.com/wxsatellite/goweb/framework/gin"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"testing"
+)
+
+func TestVersionHandler(t *testing.T) {
+	service := NewFakeService(t.TempDir())
+	service.SetVersion("v1.2.3")
+	container := framework.NewGoWebContainer()
+	container.Fake(Key, service)
+
+	engine := gin.New()
+	engine.SetContainer(container)
+	RegisterRoutes(engine)
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/version", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expect 200, got %d", w.Code)
+	}
+	var info BuildInfo
+	if err := json.Unmarshal(w.Body.Bytes(), &info); err != nil {
+		t.Fatal(err)
+	}
+	if info.Version != "v1.2.3" || info.GoVersion != runtime.Version() {
+		t.Fatalf("unexpected build info: %+v", info)
+	}
+}
@@ -43,7 +43,11 @@ func New(params ...interface{}) (interface{}, error) {
 }
 
 func (*Service) Version() string {
-	return "1.0.0"
+	return GetBuildInfo().Version
+}
+
+func (*Service) BuildInfo() BuildInfo {
+	return GetBuildInfo()
 }
 
 func (s *Service) BaseFolder() (path string) {
@@ -4,9 +4,34 @@ import "time"
 
 const Key = "goweb:config"
 
+// WatchFunc 配置变化的回调，key 为订阅的配置，oldVal 为 nil 表示新增了配置，newVal 为 nil 表示删除了配置
+type WatchFunc func(key string, oldVal interface{}, newVal interface{})
+
+// ReloadStatus 配置文件热更新的状态
+type ReloadStatus struct {
+	// LastReload 最近一次热更新的时间，启动之后没有热更新过为零值
+	LastReload time.Time
+	// Errors 加载失败的配置文件，key 为文件路径或者配置来源的名字，这些配置仍然使用上一次加载成功的值，修复之后会被移除
+	Errors map[string]error
+}
+
+// IsOk 所有配置文件都加载成功
+func (r ReloadStatus) IsOk() bool {
+	return len(r.Errors) == 0
+}
+
+// LayerValue 某一层中配置的值
+type LayerValue struct {
+	Layer     string      // 层的名字，例如 LayerDefault、LayerFlag
+	File      string      // 配置所在的文件，不是配置文件的层为来源的描述，例如 URL、environment
+	Value     interface{} // 这一层中配置的值，还没有替换 ${a.b} 引用
+	Encrypted bool        // 这一层中的值是否是加密的
+}
+
 // Config 定义了配置文件服务，读取配置文件，支持点分割的路径读取
 // 例如: .Get("app.name") 表示从app文件中读取name属性
 // 建议使用 yaml 属性, https://yaml.org/spec/1.2/spec.html
+// 同时支持 json、toml、properties 格式的配置文件，其他格式可以通过 RegisterDecoder 注册解析器
 type Config interface {
 	// IsExist 检查一个属性是否存在
 	IsExist(key string) bool
@@ -34,6 +59,28 @@ type Config interface {
 	// GetStringMapStringSlice 获取一个string为key，数组string为val的map
 	GetStringMapStringSlice(key string) map[string][]string
 
-	// Load 加载配置到某个对象
+	// Load 加载配置到某个对象，加载之后校验结构体的 validate 标签
+	// time.Duration 类型可以配置为 "5s"，Size 类型可以配置为 "10MB"
 	Load(key string, val interface{}) error
+	// LoadStrict 和 Load 一样，但是配置中存在结构体没有的字段时报错，用于发现配置的拼写错误
+	LoadStrict(key string, val interface{}) error
+
+	// Source 获取某一个配置最终生效的值来自哪一层（LayerDefault、LayerEnv、LayerLocal）以及对应的文件，配置不存在时返回空字符串
+	Source(key string) (layer string, file string)
+
+	// Layers 获取定义了某个配置的所有层以及每一层中的值，按优先级从低到高排列，最后一个是最终生效的值
+	// 优先级从低到高为：default、<APP_ENV>、local 配置目录，ConfigSource，环境变量 GOWEB_A__B，命令行参数 --config.a.b
+	Layers(key string) []LayerValue
+	// IsEncrypted 配置最终生效的值是否是在配置文件中使用 enc(xxx) 加密的，命令行输出配置的时候会隐藏这些值
+	IsEncrypted(key string) bool
+
+	// Watch 订阅某一个配置的变化，key 可以是某一项配置也可以是一个子树，例如 "log.level"、"database.mysql"
+	// 配置文件热更新之后值发生了变化才会调用回调，回调不持有配置的锁，返回的函数用于取消订阅
+	Watch(key string, callback WatchFunc) (cancel func())
+
+	// ReloadStatus 获取配置文件热更新的状态
+	ReloadStatus() ReloadStatus
+
+	// All 获取所有配置的副本，第一层 key 为文件名，嵌套的 map 统一为 map[string]interface{}
+	All() map[string]interface{}
 }
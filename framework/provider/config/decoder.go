@@ -0,0 +1,96 @@
+package config
+
+import (
+	"encoding/json"
+	"github.com/magiconair/properties"
+	"github.com/pelletier/go-toml"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Decoder 配置文件解析器，把文件内容解析成 map，第一层 key 会挂在文件名下面
+type Decoder func(content []byte) (map[string]interface{}, error)
+
+var (
+	// ErrUnsupportedFile 没有对应解析器的配置文件
+	ErrUnsupportedFile = errors.New("unsupported config file")
+	// ErrNameConflict 两个配置文件映射到了同一个名字，例如 app.yml 和 app.json
+	ErrNameConflict = errors.New("config file name conflict")
+)
+
+var (
+	decoders = map[string]Decoder{
+		"yaml":       decodeYaml,
+		"yml":        decodeYaml,
+		"json":       decodeJson,
+		"toml":       decodeToml,
+		"properties": decodeProperties,
+	}
+	decodersLock sync.RWMutex
+)
+
+// RegisterDecoder 注册某个后缀的配置文件解析器，ext 不带点，例如 "ini"，已经存在的会被覆盖
+func RegisterDecoder(ext string, decoder Decoder) {
+	decodersLock.Lock()
+	defer decodersLock.Unlock()
+	decoders[strings.ToLower(ext)] = decoder
+}
+
+// getDecoder 根据文件名获取配置名以及对应的解析器，例如 database.toml 的配置名是 database
+func getDecoder(fileName string) (string, Decoder, error) {
+	ext := filepath.Ext(fileName)
+	name := strings.TrimSuffix(fileName, ext)
+	decodersLock.RLock()
+	decoder, ok := decoders[strings.ToLower(strings.TrimPrefix(ext, "."))]
+	decodersLock.RUnlock()
+	// 配置名作为点分割路径的第一层，所以不能包含点
+	if !ok || name == "" || strings.Contains(name, ".") {
+		return "", nil, errors.Wrap(ErrUnsupportedFile, fileName)
+	}
+	return name, decoder, nil
+}
+
+func decodeYaml(content []byte) (map[string]interface{}, error) {
+	c := make(map[string]interface{})
+	if err := yaml.Unmarshal(content, &c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func decodeJson(content []byte) (map[string]interface{}, error) {
+	c := make(map[string]interface{})
+	if err := json.Unmarshal(content, &c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func decodeToml(content []byte) (map[string]interface{}, error) {
+	tree, err := toml.LoadBytes(content)
+	if err != nil {
+		return nil, err
+	}
+	return tree.ToMap(), nil
+}
+
+// decodeProperties 解析 properties 文件，点分割的 key 展开成嵌套的 map
+// 例如 mysql.host=127.0.0.1 和 yaml 中的 mysql: {host: 127.0.0.1} 等价
+func decodeProperties(content []byte) (map[string]interface{}, error) {
+	loader := &properties.Loader{Encoding: properties.UTF8, DisableExpansion: true}
+	p, err := loader.LoadBytes(content)
+	if err != nil {
+		return nil, err
+	}
+	c := make(map[string]interface{})
+	for _, key := range p.Keys() {
+		val, _ := p.Get(key)
+		if err = setPath(c, strings.Split(key, "."), val); err != nil {
+			return nil, errors.Wrap(err, "properties")
+		}
+	}
+	return c, nil
+}
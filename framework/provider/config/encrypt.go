@@ -0,0 +1,166 @@
+package config
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"github.com/pkg/errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// 配置文件中的敏感信息可以加密之后再提交，例如 password: enc(xxx)，配置服务加载的时候自动解密
+// 加密使用 AES-256-GCM，密钥按顺序从以下位置获取：
+// 环境变量 GOWEB_CONFIG_KEY、环境变量 GOWEB_CONFIG_KEY_FILE 指定的文件、配置目录下的 .key 文件
+// 密钥可以是任意字符串，经过 sha256 之后作为 AES 的密钥，建议使用随机生成的长字符串
+const (
+	EncryptKeyEnv     = "GOWEB_CONFIG_KEY"
+	EncryptKeyFileEnv = "GOWEB_CONFIG_KEY_FILE"
+	EncryptKeyFile    = ".key"
+)
+
+var (
+	// ErrNoEncryptKey 配置文件中有加密的配置，但是没有找到密钥
+	ErrNoEncryptKey = errors.New("config encrypt key not found")
+	// ErrDecryptFailed 加密的配置格式不对，或者密钥错误无法解密
+	ErrDecryptFailed = errors.New("decrypt config failed")
+)
+
+var encryptedPattern = regexp.MustCompile(`^enc\(([A-Za-z0-9+/=]+)\)$`)
+
+// LoadEncryptKey 获取配置加密的密钥，folder 为配置目录，没有配置密钥的时候返回 ErrNoEncryptKey
+func LoadEncryptKey(folder string, envMaps map[string]string) ([]byte, error) {
+	secret := envMaps[EncryptKeyEnv]
+	if secret == "" {
+		file := envMaps[EncryptKeyFileEnv]
+		if file == "" {
+			file = filepath.Join(folder, EncryptKeyFile)
+		}
+		content, err := ioutil.ReadFile(file)
+		if os.IsNotExist(err) && envMaps[EncryptKeyFileEnv] == "" {
+			return nil, ErrNoEncryptKey
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "read config encrypt key file")
+		}
+		secret = strings.TrimSpace(string(content))
+	}
+	if secret == "" {
+		return nil, ErrNoEncryptKey
+	}
+	key := sha256.Sum256([]byte(secret))
+	return key[:], nil
+}
+
+// Encrypt 加密一个配置，返回可以直接写在配置文件中的 enc(xxx)
+func Encrypt(key []byte, plaintext string) (string, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err = io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", errors.WithStack(err)
+	}
+	// 随机数放在密文的前面，解密的时候再拆出来
+	sealed := gcm.Seal(nonce, nonce, []byte(plaintext), nil)
+	return "enc(" + base64.StdEncoding.EncodeToString(sealed) + ")", nil
+}
+
+// Decrypt 解密一个配置，value 可以是 enc(xxx) 也可以是括号里面的内容
+func Decrypt(key []byte, value string) (string, error) {
+	value = strings.TrimSpace(value)
+	if groups := encryptedPattern.FindStringSubmatch(value); groups != nil {
+		value = groups[1]
+	}
+	sealed, err := base64.StdEncoding.DecodeString(value)
+	if err != nil {
+		return "", errors.Wrap(ErrDecryptFailed, "invalid encrypted config: "+err.Error())
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return "", errors.Wrap(ErrDecryptFailed, "invalid encrypted config: too short")
+	}
+	plaintext, err := gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], nil)
+	if err != nil {
+		return "", errors.Wrap(ErrDecryptFailed, "the key may be wrong: "+err.Error())
+	}
+	return string(plaintext), nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return cipher.NewGCM(block)
+}
+
+// decryptTree 解密配置中所有 enc(xxx) 格式的值，返回解密过的配置路径，prefix 为配置名
+func (s *Service) decryptTree(prefix string, tree map[string]interface{}) ([]string, error) {
+	decrypted := make([]string, 0)
+	var walk func(path string, val interface{}) (interface{}, error)
+	walk = func(path string, val interface{}) (interface{}, error) {
+		switch v := val.(type) {
+		case map[string]interface{}:
+			for name, item := range v {
+				res, err := walk(path+"."+name, item)
+				if err != nil {
+					return nil, err
+				}
+				v[name] = res
+			}
+			return v, nil
+		case []interface{}:
+			for i, item := range v {
+				res, err := walk(path, item)
+				if err != nil {
+					return nil, err
+				}
+				v[i] = res
+			}
+			return v, nil
+		case string:
+			if !encryptedPattern.MatchString(strings.TrimSpace(v)) {
+				return v, nil
+			}
+			key, err := s.encryptKey()
+			if err != nil {
+				return nil, errors.Wrapf(err, "decrypt %s", path)
+			}
+			plaintext, err := Decrypt(key, v)
+			if err != nil {
+				return nil, errors.Wrapf(err, "decrypt %s", path)
+			}
+			decrypted = append(decrypted, path)
+			return plaintext, nil
+		default:
+			return v, nil
+		}
+	}
+	if _, err := walk(prefix, tree); err != nil {
+		return nil, err
+	}
+	return decrypted, nil
+}
+
+// encryptKey 第一次用到的时候才加载密钥，没有加密配置的项目不需要配置密钥
+func (s *Service) encryptKey() ([]byte, error) {
+	if s.key == nil {
+		key, err := LoadEncryptKey(s.folder, s.envMaps)
+		if err != nil {
+			return nil, err
+		}
+		s.key = key
+	}
+	return s.key, nil
+}
@@ -0,0 +1,147 @@
+package config
+
+import (
+	"github.com/spf13/cast"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FakeService 内存中的配置服务，用于测试，不需要在磁盘上准备配置目录
+// 和 Service 一样，第一层 key 是文件名，例如 Set("database.mysql.host", "127.0.0.1")
+type FakeService struct {
+	confMaps    map[string]interface{}
+	lock        sync.RWMutex
+	subscribers subscribers
+}
+
+var _ Config = (*FakeService)(nil)
+
+// NewFakeService 创建内存配置服务，confMaps 的 key 为文件名，可以为 nil
+func NewFakeService(confMaps map[string]interface{}) *FakeService {
+	if confMaps == nil {
+		confMaps = make(map[string]interface{})
+	}
+	return &FakeService{confMaps: confMaps}
+}
+
+// Set 设置一个配置，中间不存在的层级会自动创建，值发生变化时同步调用 Watch 的回调
+func (s *FakeService) Set(key string, val interface{}) {
+	before := s.All()
+	s.set(key, val)
+	s.subscribers.notify(before, s.All())
+}
+
+func (s *FakeService) set(key string, val interface{}) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	path := strings.Split(key, ".")
+	current := s.confMaps
+	for _, name := range path[:len(path)-1] {
+		next, ok := current[name].(map[string]interface{})
+		if !ok {
+			next = cast.ToStringMap(current[name])
+			current[name] = next
+		}
+		current = next
+	}
+	current[path[len(path)-1]] = val
+}
+
+func (s *FakeService) find(key string) interface{} {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return searchMap(s.confMaps, strings.Split(key, "."))
+}
+
+func (s *FakeService) IsExist(key string) bool {
+	return s.find(key) != nil
+}
+
+func (s *FakeService) Get(key string) interface{} {
+	return s.find(key)
+}
+
+func (s *FakeService) GetBool(key string) bool {
+	return cast.ToBool(s.find(key))
+}
+
+func (s *FakeService) GetInt(key string) int {
+	return cast.ToInt(s.find(key))
+}
+
+func (s *FakeService) GetFloat64(key string) float64 {
+	return cast.ToFloat64(s.find(key))
+}
+
+func (s *FakeService) GetTime(key string) time.Time {
+	return cast.ToTime(s.find(key))
+}
+
+func (s *FakeService) GetString(key string) string {
+	return cast.ToString(s.find(key))
+}
+
+func (s *FakeService) GetIntSlice(key string) []int {
+	return cast.ToIntSlice(s.find(key))
+}
+
+func (s *FakeService) GetStringSlice(key string) []string {
+	return cast.ToStringSlice(s.find(key))
+}
+
+func (s *FakeService) GetStringMap(key string) map[string]interface{} {
+	return cast.ToStringMap(s.find(key))
+}
+
+func (s *FakeService) GetStringMapString(key string) map[string]string {
+	return cast.ToStringMapString(s.find(key))
+}
+
+func (s *FakeService) GetStringMapStringSlice(key string) map[string][]string {
+	return cast.ToStringMapStringSlice(s.find(key))
+}
+
+func (s *FakeService) Load(key string, val interface{}) error {
+	return decode(s.find(key), val, false)
+}
+
+func (s *FakeService) LoadStrict(key string, val interface{}) error {
+	return decode(s.find(key), val, true)
+}
+
+// Source 内存中的配置都认为来自 LayerEnv，没有对应的文件
+func (s *FakeService) Source(key string) (layer string, file string) {
+	if !s.IsExist(key) {
+		return "", ""
+	}
+	return LayerEnv, ""
+}
+
+// Layers 内存中的配置只有一层
+func (s *FakeService) Layers(key string) []LayerValue {
+	if !s.IsExist(key) {
+		return []LayerValue{}
+	}
+	return []LayerValue{{Layer: LayerEnv, Value: s.find(key)}}
+}
+
+// IsEncrypted 内存中的配置都不是加密的
+func (s *FakeService) IsEncrypted(key string) bool {
+	return false
+}
+
+func (s *FakeService) Watch(key string, callback WatchFunc) (cancel func()) {
+	return s.subscribers.add(key, callback)
+}
+
+// ReloadStatus 内存中的配置没有热更新，总是成功
+func (s *FakeService) ReloadStatus() ReloadStatus {
+	return ReloadStatus{Errors: map[string]error{}}
+}
+
+func (s *FakeService) All() map[string]interface{} {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return copyMap(s.confMaps)
+}
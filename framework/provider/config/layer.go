@@ -0,0 +1,95 @@
+package config
+
+import (
+	"github.com/pkg/errors"
+	"sort"
+	"strings"
+)
+
+// 配置分层，后面的层覆盖前面的层，优先级从低到高为：
+// config/default/ 是所有环境共享的默认配置，config/<APP_ENV>/ 是当前环境的配置，config/local/ 是本地覆盖配置，不提交到 git
+// 然后是 ConfigSource 配置来源（按添加的顺序），环境变量 GOWEB_LOG__LEVEL，最后是命令行参数 --config.log.level=debug
+// 同名的配置文件按层深度合并，map 递归合并，其他类型的值（包括数组）直接覆盖
+const (
+	LayerDefault = "default"
+	LayerEnv     = "env"
+	LayerLocal   = "local"
+	LayerEnviron = "environ"
+	LayerFlag    = "flag"
+)
+
+// 层的优先级，优先级相同的层按添加的顺序排列
+const (
+	priorityFile = iota
+	prioritySource
+	priorityEnviron
+	priorityFlag
+)
+
+// configLayer 一层配置，对应一个配置目录、一个配置来源或者环境变量、命令行参数
+type configLayer struct {
+	name     string                 // 层的名字，LayerDefault、LayerEnv、LayerLocal、ConfigSource 的名字、LayerEnviron、LayerFlag
+	folder   string                 // 配置目录的绝对路径，不是配置目录的层为空
+	priority int                    // 层的优先级
+	files    map[string]*configFile // key 为配置名，例如 app.yml 的配置名为 app
+}
+
+// configFile 一个配置文件解析之后的结果
+type configFile struct {
+	fileName string                 // 带后缀的文件名，不是配置目录的层为来源的描述，例如 URL
+	raw      []byte                 // 文件的原始内容
+	tree     map[string]interface{} // 解析之后的配置，嵌套的 map 统一为 map[string]interface{}
+
+	encrypted []string // 文件中加密的配置路径，例如 database.mysql.password
+}
+
+// mergeMap 把 src 深度合并到 dst 的副本中，src 中的值优先
+func mergeMap(dst map[string]interface{}, src map[string]interface{}) map[string]interface{} {
+	res := copyMap(dst)
+	for key, val := range src {
+		srcMap, srcOk := val.(map[string]interface{})
+		dstMap, dstOk := res[key].(map[string]interface{})
+		if srcOk && dstOk {
+			res[key] = mergeMap(dstMap, srcMap)
+			continue
+		}
+		res[key] = copyValue(val)
+	}
+	return res
+}
+
+// setPath 按路径设置配置，中间不存在的层级自动创建
+func setPath(tree map[string]interface{}, path []string, val interface{}) error {
+	current := tree
+	for i, name := range path[:len(path)-1] {
+		switch next := current[name].(type) {
+		case nil:
+			m := make(map[string]interface{})
+			current[name] = m
+			current = m
+		case map[string]interface{}:
+			current = next
+		default:
+			return errors.Errorf("key %s conflicts with %s", strings.Join(path, "."), strings.Join(path[:i+1], "."))
+		}
+	}
+	last := path[len(path)-1]
+	if _, ok := current[last].(map[string]interface{}); ok {
+		return errors.Errorf("key %s conflicts with nested keys", strings.Join(path, "."))
+	}
+	current[last] = val
+	return nil
+}
+
+// addLayer 添加一层配置，按优先级排序，调用方需要持有写锁
+func (s *Service) addLayer(layer *configLayer) {
+	for _, item := range s.layers {
+		if item == layer {
+			return
+		}
+	}
+	s.layers = append(s.layers, layer)
+	sort.SliceStable(s.layers, func(i, j int) bool {
+		return s.layers[i].priority < s.layers[j].priority
+	})
+}
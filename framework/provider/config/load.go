@@ -0,0 +1,149 @@
+package config
+
+import (
+	"github.com/go-playground/validator/v10"
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Size 字节大小，配置中可以写成 512、"10KB"、"1.5GiB" 这样的格式，单位都按 1024 换算
+type Size int64
+
+const (
+	Byte Size = 1 << (10 * iota)
+	KB
+	MB
+	GB
+	TB
+)
+
+var sizeUnits = map[string]Size{
+	"": Byte, "b": Byte,
+	"k": KB, "kb": KB, "kib": KB,
+	"m": MB, "mb": MB, "mib": MB,
+	"g": GB, "gb": GB, "gib": GB,
+	"t": TB, "tb": TB, "tib": TB,
+}
+
+// ParseSize 解析字节大小，例如 "10MB"
+func ParseSize(s string) (Size, error) {
+	str := strings.ToLower(strings.TrimSpace(s))
+	index := strings.IndexFunc(str, func(r rune) bool {
+		return (r < '0' || r > '9') && r != '.'
+	})
+	if index == -1 {
+		index = len(str)
+	}
+	unit, ok := sizeUnits[strings.TrimSpace(str[index:])]
+	if !ok {
+		return 0, errors.Errorf("invalid size %q: unknown unit", s)
+	}
+	num, err := strconv.ParseFloat(str[:index], 64)
+	if err != nil || num < 0 {
+		return 0, errors.Errorf("invalid size %q", s)
+	}
+	return Size(num * float64(unit)), nil
+}
+
+// stringToSizeHookFunc 把字符串配置转换为 Size
+func stringToSizeHookFunc() mapstructure.DecodeHookFuncType {
+	return func(f reflect.Type, t reflect.Type, data interface{}) (interface{}, error) {
+		if f.Kind() != reflect.String || t != reflect.TypeOf(Size(0)) {
+			return data, nil
+		}
+		return ParseSize(data.(string))
+	}
+}
+
+// validate 校验配置结构体的 validate 标签，使用 go-playground/validator
+var validate = validator.New()
+
+// decode 把配置解析到 val 中，strict 为 true 的时候配置中存在结构体没有的字段会报错
+// time.Duration 可以使用 "5s" 这样的字符串，Size 可以使用 "10MB" 这样的字符串
+// 解析完成之后校验结构体的 validate 标签
+func decode(input interface{}, val interface{}, strict bool) error {
+	decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		DecodeHook: mapstructure.ComposeDecodeHookFunc(
+			mapstructure.StringToTimeDurationHookFunc(),
+			stringToSizeHookFunc(),
+		),
+		// properties 文件以及环境变量替换之后的值都是字符串，需要允许转换为数字、布尔值
+		WeaklyTypedInput: true,
+		ErrorUnused:      strict,
+		Result:           val,
+	})
+	if err != nil {
+		return err
+	}
+	if err = decoder.Decode(input); err != nil {
+		// mapstructure 的错误是多行的，合并成一行，方便在日志和命令行中输出
+		if merr, ok := err.(*mapstructure.Error); ok {
+			msgs := make([]string, 0, len(merr.Errors))
+			for _, msg := range merr.Errors {
+				msgs = append(msgs, strings.TrimPrefix(msg, "'' "))
+			}
+			return errors.New(strings.Join(msgs, "; "))
+		}
+		return err
+	}
+	// 只有结构体才需要校验
+	v := reflect.ValueOf(val)
+	for v.Kind() == reflect.Ptr {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+	return validate.Struct(val)
+}
+
+var (
+	schemas     = make(map[string]reflect.Type)
+	schemasLock sync.RWMutex
+)
+
+// SchemaResult 一个注册的配置结构体的校验结果
+type SchemaResult struct {
+	Key  string // 配置路径，例如 database.mysql
+	Type string // 结构体类型
+	Err  error  // 校验失败的原因，成功为 nil
+}
+
+// RegisterSchema 注册某个配置对应的结构体，val 为结构体或者结构体指针
+// goweb config validate 命令会使用这个结构体严格校验当前环境的配置
+func RegisterSchema(key string, val interface{}) {
+	t := reflect.TypeOf(val)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	schemasLock.Lock()
+	defer schemasLock.Unlock()
+	schemas[key] = t
+}
+
+// ValidateSchemas 使用所有注册的结构体严格校验配置，结果按配置路径排序
+func ValidateSchemas(c Config) []SchemaResult {
+	schemasLock.RLock()
+	keys := make([]string, 0, len(schemas))
+	for key := range schemas {
+		keys = append(keys, key)
+	}
+	types := make(map[string]reflect.Type, len(schemas))
+	for key, t := range schemas {
+		types[key] = t
+	}
+	schemasLock.RUnlock()
+	sort.Strings(keys)
+
+	results := make([]SchemaResult, 0, len(keys))
+	for _, key := range keys {
+		val := reflect.New(types[key]).Interface()
+		results = append(results, SchemaResult{Key: key, Type: types[key].String(), Err: c.LoadStrict(key, val)})
+	}
+	return results
+}
@@ -0,0 +1,61 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+type mysqlConfig struct {
+	Host    string        `mapstructure:"host" validate:"required"`
+	Port    int           `mapstructure:"port" validate:"min=1,max=65535"`
+	Timeout time.Duration `mapstructure:"timeout"`
+	Buffer  Size          `mapstructure:"buffer"`
+}
+
+func TestLoad(t *testing.T) {
+	service := NewFakeService(nil)
+	service.Set("database.mysql", map[string]interface{}{
+		"host":    "127.0.0.1",
+		"port":    "3306",
+		"timeout": "1.5s",
+		"buffer":  "4MB",
+	})
+
+	conf := &mysqlConfig{}
+	if err := service.Load("database.mysql", conf); err != nil {
+		t.Fatal(err)
+	}
+	if conf.Port != 3306 || conf.Timeout != 1500*time.Millisecond || conf.Buffer != 4*MB {
+		t.Fatalf("unexpected config: %+v", conf)
+	}
+
+	// 拼写错误的字段只有严格模式才会报错
+	service.Set("database.mysql.hots", "127.0.0.1")
+	if err := service.Load("database.mysql", &mysqlConfig{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := service.LoadStrict("database.mysql", &mysqlConfig{}); err == nil || !strings.Contains(err.Error(), "hots") {
+		t.Fatalf("expect unused key error, got %v", err)
+	}
+
+	// validate 标签
+	service.Set("database.mysql.port", 0)
+	if err := service.Load("database.mysql", &mysqlConfig{}); err == nil || !strings.Contains(err.Error(), "Port") {
+		t.Fatalf("expect validate error, got %v", err)
+	}
+}
+
+func TestParseSize(t *testing.T) {
+	cases := map[string]Size{"512": 512, "10KB": 10 * KB, "1.5 GiB": GB + GB/2, "2m": 2 * MB}
+	for str, expect := range cases {
+		if size, err := ParseSize(str); err != nil || size != expect {
+			t.Errorf("%s: expect %d, got %d, %v", str, expect, size, err)
+		}
+	}
+	for _, str := range []string{"", "MB", "10XB", "-1KB"} {
+		if _, err := ParseSize(str); err == nil {
+			t.Errorf("%s: expect error", str)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package config
+
+import (
+	"github.com/pkg/errors"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// 环境变量和命令行参数可以覆盖任意一个配置：
+// 环境变量 GOWEB_LOG__LEVEL=debug 覆盖 log.level，GOWEB_ 之后用两个下划线分割配置路径，名字统一转为小写，单个下划线保留
+// 命令行参数 --config.log.level=debug 或者 --config.log.level debug 覆盖 log.level，优先级比环境变量高
+const (
+	EnvironPrefix    = "GOWEB_"
+	EnvironSeparator = "__"
+	FlagPrefix       = "--config."
+)
+
+// ParseFlags 从命令行参数中取出 --config.a.b=value 形式的参数，返回配置覆盖以及剩下的参数
+// 剩下的参数可以继续交给 cobra 解析，遇到 -- 之后不再解析
+func ParseFlags(args []string) (map[string]string, []string) {
+	overrides := make(map[string]string)
+	rest := make([]string, 0, len(args))
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			rest = append(rest, args[i:]...)
+			break
+		}
+		if !strings.HasPrefix(arg, FlagPrefix) {
+			rest = append(rest, arg)
+			continue
+		}
+		key := strings.TrimPrefix(arg, FlagPrefix)
+		if index := strings.Index(key, "="); index != -1 {
+			overrides[key[:index]] = key[index+1:]
+			continue
+		}
+		// --config.a.b value 的形式，值为下一个参数
+		if i+1 < len(args) {
+			overrides[key] = args[i+1]
+			i++
+			continue
+		}
+		overrides[key] = ""
+	}
+	return overrides, rest
+}
+
+// environOverrides 从环境变量中获取配置覆盖，例如 GOWEB_DATABASE__MYSQL__MAX_IDLE 覆盖 database.mysql.max_idle
+// 没有两个下划线的环境变量不是配置覆盖，例如 GOWEB_CONFIG_KEY
+func environOverrides(envMaps map[string]string) map[string]string {
+	overrides := make(map[string]string)
+	for key, val := range envMaps {
+		if !strings.HasPrefix(key, EnvironPrefix) || !strings.Contains(key, EnvironSeparator) {
+			continue
+		}
+		path := strings.Split(strings.ToLower(strings.TrimPrefix(key, EnvironPrefix)), EnvironSeparator)
+		overrides[strings.Join(path, ".")] = val
+	}
+	return overrides
+}
+
+// setOverrides 添加一层覆盖配置，values 的 key 为点分割的配置路径，至少要有两层，例如 log.level
+func (s *Service) setOverrides(name string, description string, priority int, values map[string]string) error {
+	if len(values) == 0 {
+		return nil
+	}
+	// 按 key 排序，保证 a.b 和 a.b.c 冲突的时候报错信息是稳定的
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	layer := &configLayer{name: name, priority: priority, files: make(map[string]*configFile)}
+	for _, key := range keys {
+		path := strings.Split(key, s.keyBreak)
+		if len(path) < 2 || path[0] == "" {
+			return errors.Errorf("invalid config override %s, should be like log.level", key)
+		}
+		file, ok := layer.files[path[0]]
+		if !ok {
+			file = &configFile{fileName: description, tree: make(map[string]interface{})}
+			layer.files[path[0]] = file
+		}
+		if err := setPath(file.tree, path[1:], values[key]); err != nil {
+			return err
+		}
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.addLayer(layer)
+	for name := range layer.files {
+		s.mergeLayers(name)
+	}
+	return nil
+}
+
+// Layers 获取定义了某个配置的所有层以及每一层中的值，按优先级从低到高排列，最后一个是最终生效的值
+func (s *Service) Layers(key string) []LayerValue {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	path := strings.Split(key, s.keyBreak)
+	res := make([]LayerValue, 0)
+	for _, layer := range s.layers {
+		file, ok := layer.files[path[0]]
+		if !ok {
+			continue
+		}
+		var val interface{} = file.tree
+		if len(path) > 1 {
+			val = searchMap(file.tree, path[1:])
+		}
+		if val == nil {
+			continue
+		}
+		encrypted := false
+		for _, item := range file.encrypted {
+			if item == key {
+				encrypted = true
+			}
+		}
+		res = append(res, LayerValue{
+			Layer:     layer.name,
+			File:      filepath.Join(layer.folder, file.fileName),
+			Value:     copyValue(val),
+			Encrypted: encrypted,
+		})
+	}
+	return res
+}
@@ -0,0 +1,59 @@
+package config
+
+import (
+	"github.com/wxsatellite/goweb/framework"
+	"reflect"
+	"testing"
+)
+
+func TestParseFlags(t *testing.T) {
+	flags, rest := ParseFlags([]string{"app", "start", "--config.log.level=debug", "--config.app.name", "demo", "--daemon", "--", "--config.a.b=c"})
+	if !reflect.DeepEqual(flags, map[string]string{"log.level": "debug", "app.name": "demo"}) {
+		t.Fatalf("unexpected flags: %v", flags)
+	}
+	if !reflect.DeepEqual(rest, []string{"app", "start", "--daemon", "--", "--config.a.b=c"}) {
+		t.Fatalf("unexpected rest args: %v", rest)
+	}
+}
+
+func TestOverrides(t *testing.T) {
+	folder := t.TempDir()
+	writeConfigFiles(t, folder, "testing", map[string]string{
+		"database.yml": "mysql:\n  host: 127.0.0.1\n  max_idle: 10\n",
+	})
+	envMaps := map[string]string{
+		"GOWEB_DATABASE__MYSQL__HOST":     "env.host",
+		"GOWEB_DATABASE__MYSQL__MAX_IDLE": "20",
+		"GOWEB_CONFIG_KEY":                "not an override",
+	}
+	flags := map[string]string{"database.mysql.host": "flag.host"}
+	instance, err := New(framework.NewGoWebContainer(), folder, "testing", envMaps, []ConfigSource{}, flags)
+	if err != nil {
+		t.Fatal(err)
+	}
+	service := instance.(*Service)
+	defer service.Close()
+
+	if service.GetString("database.mysql.host") != "flag.host" || service.GetInt("database.mysql.max_idle") != 20 {
+		t.Fatalf("unexpected config: %v", service.Get("database.mysql"))
+	}
+	if service.IsExist("config") {
+		t.Fatal("GOWEB_CONFIG_KEY should not be an override")
+	}
+
+	layers := service.Layers("database.mysql.host")
+	names := make([]string, 0, len(layers))
+	for _, layer := range layers {
+		names = append(names, layer.Layer)
+	}
+	if !reflect.DeepEqual(names, []string{LayerEnv, LayerEnviron, LayerFlag}) {
+		t.Fatalf("unexpected layers: %v", names)
+	}
+	if layers[1].Value != "env.host" || layers[2].File != "command line" {
+		t.Fatalf("unexpected layer values: %+v", layers)
+	}
+
+	if _, err = New(framework.NewGoWebContainer(), folder, "testing", map[string]string{}, []ConfigSource{}, map[string]string{"database": "x"}); err == nil {
+		t.Fatal("expect error for invalid override")
+	}
+}
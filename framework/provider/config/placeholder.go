@@ -0,0 +1,177 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/pkg/errors"
+	"github.com/spf13/cast"
+	"github.com/wxsatellite/goweb/framework/provider/env"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// 配置文件中支持的占位符：
+// env(KEY)、env(KEY, default) 替换为环境变量的值，环境变量不存在的时候使用默认值，在解析配置文件之前按文本替换，# 开头的注释行不替换
+// ${database.mysql.host} 引用其他配置的值，在所有配置文件合并之后替换，整个值只有一个引用的时候保留被引用配置的类型
+// 占位符前面加 $ 表示转义，$env(KEY) 和 $${a.b} 分别输出 env(KEY) 和 ${a.b}
+
+// ErrUnresolvedPlaceholder 配置文件中存在无法替换的占位符
+var ErrUnresolvedPlaceholder = errors.New("unresolved config placeholder")
+
+var (
+	envPattern       = regexp.MustCompile(`(\$?)env\(\s*([^,()\s]+)\s*(?:,\s*([^()]*?)\s*)?\)`)
+	referencePattern = regexp.MustCompile(`(\$?)\$\{([^{}]*)\}`)
+)
+
+// replace 配置文件也会使用环境变量的值，使用：env(xxx) 占位，因此解析配置文件的时候，需要替换成实际的环境变量值
+// 返回替换之后的内容以及没有找到环境变量也没有默认值的占位符，注释掉的行原样保留
+func replace(content []byte, lookup func(key string) (string, bool)) ([]byte, []string) {
+	unresolved := make([]string, 0)
+	lines := bytes.SplitAfter(content, []byte("\n"))
+	for i, line := range lines {
+		if bytes.HasPrefix(bytes.TrimSpace(line), []byte("#")) {
+			continue
+		}
+		lines[i] = replaceLine(line, lookup, &unresolved)
+	}
+	return bytes.Join(lines, nil), unresolved
+}
+
+// replaceLine 替换一行中的 env(KEY) 占位符，没有替换的占位符加入 unresolved
+func replaceLine(line []byte, lookup func(key string) (string, bool), unresolved *[]string) []byte {
+	return envPattern.ReplaceAllFunc(line, func(match []byte) []byte {
+		groups := envPattern.FindSubmatch(match)
+		// $env(KEY) 转义，去掉前面的 $
+		if len(groups[1]) > 0 {
+			return match[1:]
+		}
+		if val, ok := lookup(string(groups[2])); ok {
+			return []byte(val)
+		}
+		// 有逗号表示设置了默认值，env(KEY, ) 表示默认值为空字符串
+		if strings.Contains(string(match), ",") {
+			return []byte(unquote(string(groups[3])))
+		}
+		*unresolved = append(*unresolved, string(match))
+		return match
+	})
+}
+
+// lookupEnv 获取 env(KEY) 占位符的值，envMaps 中没有的时候再从 env 服务中获取，
+// env 服务中 KEY_FILE 指向的密钥文件是在获取 KEY 的时候才读取的，不在 envMaps 中
+func (s *Service) lookupEnv(key string) (string, bool) {
+	if val, ok := s.envMaps[key]; ok {
+		return val, true
+	}
+	if s.container == nil || !s.container.IsBind(env.Key) {
+		return "", false
+	}
+	instance, err := s.container.Make(env.Key)
+	if err != nil {
+		return "", false
+	}
+	envService, ok := instance.(env.Env)
+	if !ok || !envService.IsExist(key) {
+		return "", false
+	}
+	return envService.Get(key), true
+}
+
+// unquote 去掉默认值两边的引号，env(KEY, "a b") 的默认值为 a b
+func unquote(val string) string {
+	if len(val) >= 2 && (val[0] == '"' || val[0] == '\'') && val[len(val)-1] == val[0] {
+		return val[1 : len(val)-1]
+	}
+	return val
+}
+
+// referenceResolver 替换配置之间的引用
+type referenceResolver struct {
+	raw        map[string]interface{} // 合并之后还没有替换引用的配置
+	visiting   map[string]bool        // 正在替换的引用，用于检测循环引用
+	unresolved []string               // 无法替换的引用
+}
+
+// resolveReferences 替换所有配置中的 ${a.b} 引用，返回替换之后的配置以及无法替换的引用
+// 无法替换的引用保留原样
+func resolveReferences(raw map[string]interface{}) (map[string]interface{}, []string) {
+	resolver := &referenceResolver{raw: raw, visiting: make(map[string]bool), unresolved: make([]string, 0)}
+	resolved := make(map[string]interface{}, len(raw))
+	for name, val := range raw {
+		resolved[name] = resolver.resolve(name, val)
+	}
+	// 同一个引用可能被多次替换，去掉重复的错误
+	unresolved := make([]string, 0, len(resolver.unresolved))
+	exists := make(map[string]bool)
+	for _, item := range resolver.unresolved {
+		if !exists[item] {
+			exists[item] = true
+			unresolved = append(unresolved, item)
+		}
+	}
+	sort.Strings(unresolved)
+	return resolved, unresolved
+}
+
+// resolve 递归替换一个配置中的引用，key 为当前配置的路径，用于输出错误信息
+func (r *referenceResolver) resolve(key string, val interface{}) interface{} {
+	switch v := val.(type) {
+	case map[string]interface{}:
+		res := make(map[string]interface{}, len(v))
+		for name, item := range v {
+			res[name] = r.resolve(key+"."+name, item)
+		}
+		return res
+	case []interface{}:
+		res := make([]interface{}, len(v))
+		for i, item := range v {
+			res[i] = r.resolve(fmt.Sprintf("%s[%d]", key, i), item)
+		}
+		return res
+	case string:
+		return r.resolveString(key, v)
+	default:
+		return v
+	}
+}
+
+// resolveString 替换字符串中的引用
+func (r *referenceResolver) resolveString(key string, val string) interface{} {
+	// 整个值就是一个引用，保留被引用配置的类型，例如 port: ${database.mysql.port}
+	if loc := referencePattern.FindStringSubmatchIndex(val); loc != nil && loc[0] == 0 && loc[1] == len(val) && loc[2] == loc[3] {
+		ref := val[loc[4]:loc[5]]
+		if res, ok := r.lookup(key, ref); ok {
+			return res
+		}
+		return val
+	}
+	return referencePattern.ReplaceAllStringFunc(val, func(match string) string {
+		groups := referencePattern.FindStringSubmatch(match)
+		// $${a.b} 转义，去掉前面的 $
+		if groups[1] != "" {
+			return match[1:]
+		}
+		if res, ok := r.lookup(key, groups[2]); ok {
+			return cast.ToString(res)
+		}
+		return match
+	})
+}
+
+// lookup 获取被引用配置替换之后的值
+func (r *referenceResolver) lookup(key string, ref string) (interface{}, bool) {
+	ref = strings.TrimSpace(ref)
+	if r.visiting[ref] {
+		r.unresolved = append(r.unresolved, fmt.Sprintf("${%s} in %s: circular reference", ref, key))
+		return nil, false
+	}
+	val := searchMap(r.raw, strings.Split(ref, "."))
+	if val == nil {
+		r.unresolved = append(r.unresolved, fmt.Sprintf("${%s} in %s: config not exist", ref, key))
+		return nil, false
+	}
+	r.visiting[ref] = true
+	defer delete(r.visiting, ref)
+	return r.resolve(ref, val), true
+}
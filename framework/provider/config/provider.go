@@ -4,29 +4,56 @@ import (
 	"github.com/wxsatellite/goweb/framework"
 	"github.com/wxsatellite/goweb/framework/provider/app"
 	"github.com/wxsatellite/goweb/framework/provider/env"
+	"github.com/wxsatellite/goweb/framework/provider/health"
+	"os"
 )
 
 type Provider struct {
+	// Sources 配置文件之外的配置来源，例如 NewHTTPSource，按顺序叠加在配置文件之上
+	Sources []ConfigSource
+	// Args 命令行参数，用于解析 --config.a.b=value 形式的配置覆盖，为 nil 的时候使用 os.Args[1:]
+	Args []string
+
 	folder  string
 	env     string
 	envMaps map[string]string
+	flags   map[string]string
 }
 
 func (p *Provider) Register(container framework.Container) framework.NewInstance {
+	env.Declare(
+		env.Var{Name: EncryptKeyEnv, Description: "配置加密的密钥"},
+		env.Var{Name: EncryptKeyFileEnv, Description: "配置加密的密钥文件"},
+	)
 	return New
 }
 
+// Tags 配置服务参与就绪检查，检查是否有配置文件加载成功
+func (p *Provider) Tags() []string {
+	return []string{health.CheckerTag}
+}
+
+// Depends 配置服务需要 app 服务获取配置目录，需要 env 服务获取当前环境
+func (p *Provider) Depends() []string {
+	return []string{app.Key, env.Key}
+}
+
 func (p *Provider) Boot(container framework.Container) (err error) {
 	appService := container.MustMake(app.Key).(app.App)
 	envService := container.MustMake(env.Key).(env.Env)
 	p.folder = appService.ConfigFolder()
 	p.env = envService.AppEnv()
 	p.envMaps = envService.All()
+	args := p.Args
+	if args == nil {
+		args = os.Args[1:]
+	}
+	p.flags, _ = ParseFlags(args)
 	return
 }
 
 func (p *Provider) Params(container framework.Container) []interface{} {
-	return []interface{}{container, p.folder, p.env, p.envMaps}
+	return []interface{}{container, p.folder, p.env, p.envMaps, p.Sources, p.flags}
 }
 
 func (p *Provider) Name() string {
@@ -0,0 +1,114 @@
+package config
+
+import (
+	"context"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// kubernetesDataDir Kubernetes 挂载 ConfigMap 的时候，配置文件是指向 ..data 目录的软链接，更新的时候原子替换 ..data
+const kubernetesDataDir = "..data"
+
+// logKey 日志服务的 key，日志服务依赖配置服务，这里不能直接引用 log 包
+const logKey = "goweb:log"
+
+// errorLogger 日志服务中用于输出错误的方法
+type errorLogger interface {
+	Error(ctx context.Context, msg string, fields map[string]interface{})
+}
+
+// reload 重新加载修改过的配置文件，文件已经不存在的删除对应的配置，加载完成之后通知订阅者
+// 加载失败的文件保留上一次加载成功的配置，错误通过日志服务输出，并且可以通过 ReloadStatus 获取
+func (s *Service) reload(files map[string]*configLayer, rescan map[*configLayer]bool) {
+	before := s.All()
+	for layer := range rescan {
+		s.rescanLayer(layer)
+	}
+	for path, layer := range files {
+		if rescan[layer] {
+			continue
+		}
+		fileName := filepath.Base(path)
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			_ = s.removeConfigFile(layer, fileName)
+			continue
+		}
+		if err := s.handleConfigFile(layer, fileName); err != nil {
+			s.logReloadError(path, err)
+		}
+	}
+	s.lock.Lock()
+	s.reloadTime = time.Now()
+	unresolved := s.unresolved
+	s.lock.Unlock()
+	// 无法替换的引用保留原样，只输出错误
+	for _, item := range unresolved {
+		s.logReloadError(item, ErrUnresolvedPlaceholder)
+	}
+	s.subscribers.notify(before, s.All())
+}
+
+// rescanLayer 重新加载某一层目录下所有的配置文件，已经不存在的文件删除对应的配置
+func (s *Service) rescanLayer(layer *configLayer) {
+	entries, err := os.ReadDir(layer.folder)
+	if err != nil {
+		s.logReloadError(layer.folder, err)
+		return
+	}
+	exists := make(map[string]bool)
+	for _, entry := range entries {
+		fileName := entry.Name()
+		if entry.IsDir() || strings.HasPrefix(fileName, ".") {
+			continue
+		}
+		if _, _, err := getDecoder(fileName); err != nil {
+			continue
+		}
+		exists[fileName] = true
+		if err := s.handleConfigFile(layer, fileName); err != nil {
+			s.logReloadError(filepath.Join(layer.folder, fileName), err)
+		}
+	}
+
+	s.lock.RLock()
+	removed := make([]string, 0)
+	for _, file := range layer.files {
+		if !exists[file.fileName] {
+			removed = append(removed, file.fileName)
+		}
+	}
+	s.lock.RUnlock()
+	for _, fileName := range removed {
+		_ = s.removeConfigFile(layer, fileName)
+	}
+}
+
+// logReloadError 输出配置文件加载失败的错误，绑定了日志服务的时候使用日志服务输出
+func (s *Service) logReloadError(path string, err error) {
+	if s.container.IsBind(logKey) {
+		if instance, makeErr := s.container.Make(logKey); makeErr == nil {
+			if logger, ok := instance.(errorLogger); ok {
+				logger.Error(context.Background(), "加载配置文件失败，继续使用上一次加载成功的配置", map[string]interface{}{
+					"file":  path,
+					"error": err.Error(),
+				})
+				return
+			}
+		}
+	}
+	log.Println("加载配置文件失败，继续使用上一次加载成功的配置：", path, err)
+}
+
+// ReloadStatus 获取配置文件热更新的状态
+func (s *Service) ReloadStatus() ReloadStatus {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	status := ReloadStatus{LastReload: s.reloadTime, Errors: make(map[string]error, len(s.reloadErrors))}
+	for path, err := range s.reloadErrors {
+		status.Errors[path] = err
+	}
+	return status
+}
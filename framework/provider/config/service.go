@@ -1,15 +1,13 @@
 package config
 
 import (
-	"bytes"
+	"context"
 	"fmt"
 	"github.com/fsnotify/fsnotify"
-	"github.com/mitchellh/mapstructure"
 	"github.com/pkg/errors"
 	"github.com/spf13/cast"
 	"github.com/wxsatellite/goweb/framework"
 	"github.com/wxsatellite/goweb/framework/provider/app"
-	"gopkg.in/yaml.v2"
 	"io/ioutil"
 	"log"
 	"os"
@@ -19,18 +17,29 @@ import (
 	"time"
 )
 
-// TODO: 实现可以通过类似这样子的命令 `./goweb config get "database.mysql"` 获取配置信息
-
 // Service 配置文件服务
 // database.mysql.password 获取database.yaml文件中mysql配置对应的password字段
+// 配置按 default、<APP_ENV>、local 三层加载，同名配置文件深度合并，见 layer.go
 type Service struct {
 	container framework.Container
 	folder    string // 配置文件目录
 	keyBreak  string // 路径分隔符，默认是 "."
 
-	envMaps  map[string]string      // 所有环境变量
-	confMaps map[string]interface{} // 配置文件结构，key为文件名
-	confRaws map[string][]byte      // 配置文件的原始信息
+	envMaps    map[string]string      // 所有环境变量
+	mergedMaps map[string]interface{} // 各层合并之后、替换引用之前的配置，key为文件名
+	confMaps   map[string]interface{} // 替换引用之后的配置文件结构，key为文件名
+	unresolved []string               // 无法替换的配置引用
+	layers     []*configLayer         // 配置分层，后面的层覆盖前面的层
+	key        []byte                 // 解密 enc(xxx) 配置的密钥，用到的时候才加载
+
+	watcher      *fsnotify.Watcher // 监控配置文件目录的修改
+	debounce     time.Duration     // 合并配置文件修改事件的等待时间
+	subscribers  subscribers       // 配置变化的订阅
+	reloadTime   time.Time         // 最近一次热更新的时间
+	reloadErrors map[string]error  // 加载失败的配置文件，key 为文件路径或者配置来源的名字
+
+	ctx    context.Context    // 配置来源加载时使用，服务关闭的时候取消
+	cancel context.CancelFunc // 停止配置来源的定时拉取
 
 	// 由于在运行时增加了对 confMaps 的写操作（配置文件热更新）所以需要对 confMaps 进行锁设置，以防止在写 confMaps 的时候，读操作进入读取了错误信息。
 	// 其次：目前这个场景，读明显多于写。所以我们的锁是一个读写锁，读写锁可以让多个读并发读，但是只要有一个写操作，读和写都需要等待。
@@ -45,57 +54,134 @@ func New(params ...interface{}) (interface{}, error) {
 	folder := params[1].(string)
 	env := params[2].(string)
 	envMaps := params[3].(map[string]string)
+	// 可选的配置来源，叠加在配置文件之上
+	var sources []ConfigSource
+	if len(params) > 4 {
+		sources, _ = params[4].([]ConfigSource)
+	}
+	// 可选的命令行参数覆盖，优先级最高
+	var flags map[string]string
+	if len(params) > 5 {
+		flags, _ = params[5].(map[string]string)
+	}
 
+	folder, err := filepath.Abs(folder)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
 	envFolder := filepath.Join(folder, env)
+	defaultFolder := filepath.Join(folder, LayerDefault)
 
-	// 检测配置文件路径是否存在
+	// 检测配置文件路径是否存在，环境目录和默认目录至少要有一个
 	if _, err := os.Stat(envFolder); os.IsNotExist(err) {
-		return nil, errors.New("folder " + envFolder + " not exist: " + err.Error())
+		if _, defaultErr := os.Stat(defaultFolder); os.IsNotExist(defaultErr) {
+			return nil, errors.New("folder " + envFolder + " not exist: " + err.Error())
+		}
 	}
 	service := &Service{
 		container: container,
 		folder:    folder,
 		keyBreak:  ".",
 		envMaps:   envMaps,
-		// key是文件名，value是yaml.Unmarshal的结果
-		confMaps: make(map[string]interface{}),
-		// key是文件名，value是文件内容
-		confRaws: make(map[string][]byte),
-		lock:     sync.RWMutex{},
+		// key是文件名，value是各层合并之后的结果
+		mergedMaps: make(map[string]interface{}),
+		confMaps:   make(map[string]interface{}),
+		layers: []*configLayer{
+			{name: LayerDefault, folder: defaultFolder, files: make(map[string]*configFile)},
+			{name: LayerEnv, folder: envFolder, files: make(map[string]*configFile)},
+			{name: LayerLocal, folder: filepath.Join(folder, LayerLocal), files: make(map[string]*configFile)},
+		},
+		debounce:     DefaultWatchDebounce,
+		reloadErrors: make(map[string]error),
+		lock:         sync.RWMutex{},
 	}
 
-	//  获取目录下所有的配置文件
-	files, err := os.ReadDir(envFolder)
+	/* 监控配置文件的修改 */
+	watch, err := fsnotify.NewWatcher()
 	if err != nil {
-		return nil, errors.WithStack(err)
+		return nil, err
 	}
-	// 获取每一个文件
-	for _, file := range files {
-		// 只读取文件
-		if file.IsDir() {
+
+	placeholderErrs := make([]string, 0)
+	for _, layer := range service.layers {
+		//  获取目录下所有的配置文件，不存在的层直接跳过
+		files, err := os.ReadDir(layer.folder)
+		if os.IsNotExist(err) {
 			continue
 		}
-		if err = service.handleConfigFile(file.Name(), envFolder); err != nil {
-			fmt.Println(fmt.Sprintf("加载配置文件失败：%s，错误：%v", file.Name(), err))
-			continue
+		if err != nil {
+			_ = watch.Close()
+			return nil, errors.WithStack(err)
+		}
+		// 获取每一个文件
+		for _, file := range files {
+			// 只读取文件，隐藏文件（例如 Kubernetes 的 ..data 软链接）直接忽略
+			if file.IsDir() || strings.HasPrefix(file.Name(), ".") {
+				continue
+			}
+			if err = service.handleConfigFile(layer, file.Name()); err != nil {
+				// 两个文件映射到同一个配置名的时候无法确定使用哪一个，直接报错
+				if errors.Cause(err) == ErrNameConflict {
+					_ = watch.Close()
+					return nil, err
+				}
+				// 加密的配置无法解密的时候也直接报错，否则会在缺少数据库密码这类配置的情况下启动
+				if cause := errors.Cause(err); cause == ErrNoEncryptKey || cause == ErrDecryptFailed {
+					_ = watch.Close()
+					return nil, errors.Wrap(err, filepath.Join(layer.folder, file.Name()))
+				}
+				// 无法替换的占位符统一在最后报错
+				if errors.Cause(err) == ErrUnresolvedPlaceholder {
+					placeholderErrs = append(placeholderErrs, strings.TrimSuffix(err.Error(), ": "+ErrUnresolvedPlaceholder.Error()))
+					continue
+				}
+				fmt.Println(fmt.Sprintf("加载配置文件失败：%s，错误：%v", filepath.Join(layer.folder, file.Name()), err))
+				continue
+			}
+		}
+		// 监控配置文件目录下文件的修改，启动之后才创建的目录不会被监控
+		if err = watch.Add(layer.folder); err != nil {
+			// fsnotify使用了操作系统接口，监听器中保存了系统资源的句柄，所以使用后需要关闭
+			_ = watch.Close()
+			return nil, err
 		}
 	}
-
-	/* 监控配置文件的修改 */
-	watch, err := fsnotify.NewWatcher()
-	if err != nil {
+	// 环境变量和命令行参数覆盖配置文件
+	if err = service.setOverrides(LayerEnviron, "environment", priorityEnviron, environOverrides(envMaps)); err != nil {
+		_ = watch.Close()
 		return nil, err
 	}
-	// 监控配置文件目录下文件的修改
-	err = watch.Add(envFolder)
-	if err != nil {
-		// fsnotify使用了操作系统接口，监听器中保存了系统资源的句柄，所以使用后需要关闭
+	if err = service.setOverrides(LayerFlag, "command line", priorityFlag, flags); err != nil {
 		_ = watch.Close()
 		return nil, err
 	}
+
+	// 所有配置文件加载完成之后，配置之间的引用才能全部替换，启动的时候存在无法替换的占位符直接报错
+	placeholderErrs = append(placeholderErrs, service.unresolved...)
+	if len(placeholderErrs) > 0 {
+		_ = watch.Close()
+		return nil, errors.Wrap(ErrUnresolvedPlaceholder, strings.Join(placeholderErrs, "; "))
+	}
+	service.watcher = watch
+	service.ctx, service.cancel = context.WithCancel(context.Background())
+	for _, source := range sources {
+		if err = service.AddSource(source); err != nil {
+			_ = service.Close()
+			return nil, errors.Wrapf(err, "load config source %s", source.Name())
+		}
+	}
 	go func() {
+		// 等待合并的文件修改，key 为文件的绝对路径
+		pending := make(map[string]*configLayer)
+		// 需要重新扫描的目录
+		rescan := make(map[*configLayer]bool)
+		var debounce *time.Timer
+		var debounceC <-chan time.Time
 		defer func() {
 			_ = watch.Close()
+			if debounce != nil {
+				debounce.Stop()
+			}
 			if err := recover(); err != nil {
 				fmt.Println(err)
 			}
@@ -103,26 +189,64 @@ func New(params ...interface{}) (interface{}, error) {
 
 		for {
 			select {
-			case ev := <-watch.Events:
+			case ev, ok := <-watch.Events:
+				// watcher 被关闭了，退出监控
+				if !ok {
+					return
+				}
 				// 判断事件的类型
 				// ev.Name 的值类似：/Users/weixin/Desktop/goweb/config/development/app.yml
 				path, _ := filepath.Abs(ev.Name) // 获取绝对路径，如果已经是绝对路径就不做任何操作
 				index := strings.LastIndex(path, string(os.PathSeparator))
 				folder := path[:index]
 				fileName := path[index+1:]
-				if ev.Op&fsnotify.Create == fsnotify.Create {
-					log.Println("创建文件 : ", ev.Name)
-					_ = service.handleConfigFile(fileName, folder)
+				layer := service.findLayer(folder)
+				if layer == nil {
+					continue
 				}
-				if ev.Op&fsnotify.Write == fsnotify.Write {
-					log.Println("写入文件 : ", ev.Name)
-					_ = service.handleConfigFile(fileName, folder)
+				// Kubernetes 挂载的 ConfigMap 通过替换 ..data 软链接更新，配置文件本身不会有事件，需要重新扫描整个目录
+				if fileName == kubernetesDataDir {
+					log.Println("配置目录更新 : ", folder)
+					rescan[layer] = true
+					if debounce != nil {
+						debounce.Stop()
+					}
+					debounce = time.NewTimer(service.debounce)
+					debounceC = debounce.C
+					continue
 				}
-				if ev.Op&fsnotify.Remove == fsnotify.Remove {
+				// 不支持的文件（例如编辑器的临时文件）直接忽略
+				if _, _, err := getDecoder(fileName); err != nil {
+					continue
+				}
+				switch {
+				case ev.Op&fsnotify.Create == fsnotify.Create:
+					log.Println("创建文件 : ", ev.Name)
+				case ev.Op&fsnotify.Write == fsnotify.Write:
+					log.Println("写入文件 : ", ev.Name)
+				case ev.Op&fsnotify.Remove == fsnotify.Remove:
 					log.Println("删除文件 : ", ev.Name)
-					_ = service.removeConfigFile(fileName, folder)
+				case ev.Op&fsnotify.Rename == fsnotify.Rename:
+					log.Println("重命名文件 : ", ev.Name)
+				default:
+					continue
+				}
+				// 编辑器保存一次会触发多个事件，等待一段时间没有新的事件之后再统一重新加载
+				pending[path] = layer
+				if debounce != nil {
+					debounce.Stop()
+				}
+				debounce = time.NewTimer(service.debounce)
+				debounceC = debounce.C
+			case <-debounceC:
+				debounceC = nil
+				service.reload(pending, rescan)
+				pending = make(map[string]*configLayer)
+				rescan = make(map[*configLayer]bool)
+			case err, ok := <-watch.Errors:
+				if !ok {
+					return
 				}
-			case err := <-watch.Errors:
 				log.Println("监控配置文件错误：", err)
 			}
 		}
@@ -130,59 +254,178 @@ func New(params ...interface{}) (interface{}, error) {
 	return service, nil
 }
 
+// Close 关闭配置文件目录的监控，容器 Shutdown 的时候调用
+func (s *Service) Close() error {
+	// 停止配置来源的定时拉取
+	if s.cancel != nil {
+		s.cancel()
+	}
+	if s.watcher == nil {
+		return nil
+	}
+	return s.watcher.Close()
+}
+
+// HealthCheck 就绪检查：至少要有一个配置文件加载成功
+func (s *Service) HealthCheck(ctx context.Context) error {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	if len(s.confMaps) == 0 {
+		return errors.New("no config file loaded")
+	}
+	return nil
+}
+
+// Watch 订阅某一个配置的变化
+func (s *Service) Watch(key string, callback WatchFunc) (cancel func()) {
+	return s.subscribers.add(key, callback)
+}
+
+// findLayer 根据配置目录找到对应的层
+func (s *Service) findLayer(folder string) *configLayer {
+	for _, layer := range s.layers {
+		if layer.folder == folder {
+			return layer
+		}
+	}
+	return nil
+}
+
 // removeConfigFile 删除内存中的配置文件信息
-func (s *Service) removeConfigFile(fileName string, envFolder string) (err error) {
+func (s *Service) removeConfigFile(layer *configLayer, fileName string) (err error) {
 	s.lock.Lock()
 	defer s.lock.Unlock()
-	splits := strings.Split(fileName, ".")
-	// 只处理 yaml 或者 yml 结尾的配置文件
-	if !(len(splits) == 2 && (splits[1] == "yaml" || splits[1] == "yml")) {
-		return errors.New("只支持处理yaml、yml后缀的文件")
-	}
-	// 删除
-	delete(s.confMaps, splits[0])
-	delete(s.confRaws, splits[0])
+	name, _, err := getDecoder(fileName)
+	if err != nil {
+		return err
+	}
+	delete(s.reloadErrors, filepath.Join(layer.folder, fileName))
+	// 配置名当前对应的是另外一个文件，不需要删除
+	if file, ok := layer.files[name]; !ok || file.fileName != fileName {
+		return nil
+	}
+	// 删除之后重新合并，其他层的同名配置仍然生效
+	delete(layer.files, name)
+	s.mergeLayers(name)
 	return
 }
 
 // handleConfigFile 更新内存中的配置文件信息
-func (s *Service) handleConfigFile(fileName string, envFolder string) (err error) {
+func (s *Service) handleConfigFile(layer *configLayer, fileName string) (err error) {
 	s.lock.Lock()
 	defer s.lock.Unlock()
-	splits := strings.Split(fileName, ".")
-	// 只处理 yaml 或者 yml 结尾的配置文件
-	if !(len(splits) == 2 && (splits[1] == "yaml" || splits[1] == "yml")) {
-		return errors.New("只支持处理yaml、yml后缀的文件")
+	// 根据后缀获取解析器，例如 database.toml 使用 toml 解析器，配置名为 database
+	name, decoder, err := getDecoder(fileName)
+	if err != nil {
+		return err
+	}
+	// 记录加载的结果，加载失败的时候保留上一次加载成功的配置
+	path := filepath.Join(layer.folder, fileName)
+	defer func() {
+		if err != nil {
+			s.reloadErrors[path] = err
+			return
+		}
+		delete(s.reloadErrors, path)
+	}()
+	if loaded, ok := layer.files[name]; ok && loaded.fileName != fileName {
+		return errors.Wrapf(ErrNameConflict, "%s and %s in %s are both named %s", loaded.fileName, fileName, layer.folder, name)
 	}
-	name := splits[0]
 	// 读取文件内容
 	var bf []byte
-	bf, err = ioutil.ReadFile(filepath.Join(envFolder, fileName))
+	bf, err = ioutil.ReadFile(path)
 	if err != nil {
 		return
 	}
-	s.confRaws[name] = bf
-
-	// 将环境变量占位替换成环境变量的值
-	bf = replace(bf, s.envMaps)
+	raw := bf
 
-	// 解析 yaml
-	c := make(map[string]interface{})
+	// 将环境变量占位替换成环境变量的值，没有找到环境变量也没有默认值的时候不加载这个文件
+	bf, unresolved := replace(bf, s.lookupEnv)
+	if len(unresolved) > 0 {
+		err = errors.Wrapf(ErrUnresolvedPlaceholder, "%s: %s", path, strings.Join(unresolved, ", "))
+		return
+	}
 
-	if err = yaml.Unmarshal(bf, &c); err != nil {
+	// 解析配置文件
+	c, err := decoder(bf)
+	if err != nil {
 		return
 	}
-	// 文件名为key
-	s.confMaps[name] = c
+	// 解密 enc(xxx) 格式的配置
+	tree := copyMap(c)
+	encrypted, err := s.decryptTree(name, tree)
+	if err != nil {
+		return
+	}
+	layer.files[name] = &configFile{fileName: fileName, raw: raw, tree: tree, encrypted: encrypted}
+	s.mergeLayers(name)
+	return
+}
+
+// mergeLayers 按层合并某一个配置名的所有配置文件，调用方需要持有写锁
+func (s *Service) mergeLayers(name string) {
+	var merged map[string]interface{}
+	for _, layer := range s.layers {
+		if file, ok := layer.files[name]; ok {
+			merged = mergeMap(merged, file.tree)
+		}
+	}
+	if merged == nil {
+		delete(s.mergedMaps, name)
+	} else {
+		// 文件名为key
+		s.mergedMaps[name] = merged
+	}
+	// 其他配置可能引用了这个配置，所有配置都需要重新替换引用
+	s.confMaps, s.unresolved = resolveReferences(s.mergedMaps)
 
 	// 如果文件是 app.yml 那么需要更新一下app服务的默认目录路径
 	if name == "app" && s.container.IsBind(app.Key) {
-		if path, ok := c["path"]; ok {
+		if path, ok := searchMap(s.confMaps, []string{"app", "path"}).(map[string]interface{}); ok {
 			appService := s.container.MustMake(app.Key).(app.App)
 			appService.LoadAppConfig(cast.ToStringMapString(path))
 		}
 	}
-	return
+}
+
+// Source 获取某一个配置最终生效的值来自哪一层以及哪个文件，配置不存在的时候返回空字符串
+// 如果配置是一个 map，返回包含它的最上层，map 中的子配置可能来自不同的层
+func (s *Service) Source(key string) (layer string, file string) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	if l, f := s.source(key); f != nil {
+		return l.name, filepath.Join(l.folder, f.fileName)
+	}
+	return "", ""
+}
+
+// IsEncrypted 配置最终生效的值是否是在配置文件中加密的
+func (s *Service) IsEncrypted(key string) bool {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	if _, f := s.source(key); f != nil {
+		for _, encrypted := range f.encrypted {
+			if encrypted == key {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// source 查找配置最终生效的值所在的层以及文件，调用方需要持有读锁
+func (s *Service) source(key string) (*configLayer, *configFile) {
+	path := strings.Split(key, s.keyBreak)
+	for i := len(s.layers) - 1; i >= 0; i-- {
+		configFile, ok := s.layers[i].files[path[0]]
+		if !ok {
+			continue
+		}
+		if len(path) == 1 || searchMap(configFile.tree, path[1:]) != nil {
+			return s.layers[i], configFile
+		}
+	}
+	return nil, nil
 }
 
 // IsExist check setting is exist
@@ -247,19 +490,45 @@ func (s *Service) GetStringMapStringSlice(key string) map[string][]string {
 
 // Load a config to a struct, val should be an pointer
 func (s *Service) Load(key string, val interface{}) error {
-	return mapstructure.Decode(s.find(key), val)
+	return decode(s.find(key), val, false)
+}
+
+// LoadStrict load a config to a struct, return error if the config has keys which the struct does not have
+func (s *Service) LoadStrict(key string, val interface{}) error {
+	return decode(s.find(key), val, true)
+}
+
+// All 获取所有配置的副本
+func (s *Service) All() map[string]interface{} {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return copyMap(s.confMaps)
 }
 
-// replace 配置文件也会使用环境变量的值，使用：env(xxx) 占位，因此解析配置文件的时候，需要替换成实际的环境变量值
-func replace(content []byte, envMaps map[string]string) []byte {
-	if envMaps == nil {
-		return content
+// copyMap 深拷贝配置，yaml 解析出来的 map[interface{}]interface{} 转换为 map[string]interface{}，方便序列化为 json
+func copyMap(source map[string]interface{}) map[string]interface{} {
+	res := make(map[string]interface{}, len(source))
+	for key, val := range source {
+		res[key] = copyValue(val)
 	}
-	for key, val := range envMaps {
-		reKey := fmt.Sprintf("env(%s)", key)
-		content = bytes.ReplaceAll(content, []byte(reKey), []byte(val))
+	return res
+}
+
+func copyValue(val interface{}) interface{} {
+	switch v := val.(type) {
+	case map[string]interface{}:
+		return copyMap(v)
+	case map[interface{}]interface{}:
+		return copyMap(cast.ToStringMap(v))
+	case []interface{}:
+		res := make([]interface{}, len(v))
+		for i, item := range v {
+			res[i] = copyValue(item)
+		}
+		return res
+	default:
+		return v
 	}
-	return content
 }
 
 // find 获取某一个配置
@@ -0,0 +1,370 @@
+package config
+
+import (
+	"github.com/pkg/errors"
+	"github.com/wxsatellite/goweb/framework"
+	"github.com/wxsatellite/goweb/framework/provider/env"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeConfigFiles 在 folder/env 目录下写入配置文件
+func writeConfigFiles(t *testing.T, folder string, env string, files map[string]string) {
+	envFolder := filepath.Join(folder, env)
+	if err := os.MkdirAll(envFolder, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(envFolder, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestNew_Decoders(t *testing.T) {
+	folder := t.TempDir()
+	writeConfigFiles(t, folder, "testing", map[string]string{
+		"app.yml":           "name: goweb\n",
+		"database.json":     `{"mysql": {"host": "env(DB_HOST)", "port": 3306}}`,
+		"cache.toml":        "[redis]\nhost = \"127.0.0.1\"\ndb = 2\n",
+		"queue.properties":  "kafka.brokers=a,b\nkafka.topic = orders\n",
+		"README.md":         "ignored",
+		"app.local.yml.swp": "ignored",
+	})
+
+	instance, err := New(framework.NewGoWebContainer(), folder, "testing", map[string]string{"DB_HOST": "db.local"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	service := instance.(*Service)
+	defer service.Close()
+
+	expect := map[string]interface{}{
+		"app.name":            "goweb",
+		"database.mysql.host": "db.local",
+		"database.mysql.port": 3306,
+		"cache.redis.host":    "127.0.0.1",
+		"cache.redis.db":      2,
+		"queue.kafka.brokers": "a,b",
+		"queue.kafka.topic":   "orders",
+	}
+	for key, val := range expect {
+		switch v := val.(type) {
+		case int:
+			if got := service.GetInt(key); got != v {
+				t.Errorf("%s: expect %d, got %d", key, v, got)
+			}
+		case string:
+			if got := service.GetString(key); got != v {
+				t.Errorf("%s: expect %s, got %s", key, v, got)
+			}
+		}
+	}
+}
+
+func TestNew_NameConflict(t *testing.T) {
+	folder := t.TempDir()
+	writeConfigFiles(t, folder, "testing", map[string]string{
+		"app.yml":  "name: goweb\n",
+		"app.json": `{"name": "goweb"}`,
+	})
+
+	_, err := New(framework.NewGoWebContainer(), folder, "testing", map[string]string{})
+	if errors.Cause(err) != ErrNameConflict {
+		t.Fatalf("expect name conflict error, got %v", err)
+	}
+}
+
+func TestNew_Layers(t *testing.T) {
+	folder := t.TempDir()
+	writeConfigFiles(t, folder, LayerDefault, map[string]string{
+		"database.yml": "mysql:\n  host: 127.0.0.1\n  port: 3306\nredis:\n  host: 127.0.0.1\n",
+		"cache.yml":    "ttl: 60\n",
+	})
+	writeConfigFiles(t, folder, "production", map[string]string{
+		"database.json": `{"mysql": {"host": "db.prod"}}`,
+	})
+	writeConfigFiles(t, folder, LayerLocal, map[string]string{
+		"database.toml": "[redis]\nhost = \"localhost\"\n",
+	})
+
+	instance, err := New(framework.NewGoWebContainer(), folder, "production", map[string]string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	service := instance.(*Service)
+	defer service.Close()
+
+	expect := map[string][2]string{
+		"database.mysql.host": {"db.prod", LayerEnv},
+		"database.mysql.port": {"3306", LayerDefault},
+		"database.redis.host": {"localhost", LayerLocal},
+		"cache.ttl":           {"60", LayerDefault},
+	}
+	for key, val := range expect {
+		if got := service.GetString(key); got != val[0] {
+			t.Errorf("%s: expect %s, got %s", key, val[0], got)
+		}
+		if layer, _ := service.Source(key); layer != val[1] {
+			t.Errorf("%s: expect layer %s, got %s", key, val[1], layer)
+		}
+	}
+	if layer, file := service.Source("database.mysql.user"); layer != "" || file != "" {
+		t.Errorf("unexpected source of missing key: %s %s", layer, file)
+	}
+}
+
+func TestService_Watch(t *testing.T) {
+	folder := t.TempDir()
+	writeConfigFiles(t, folder, "testing", map[string]string{
+		"database.yml": "mysql:\n  host: 127.0.0.1\n  port: 3306\n",
+	})
+	instance, err := New(framework.NewGoWebContainer(), folder, "testing", map[string]string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	service := instance.(*Service)
+	defer service.Close()
+
+	changes := make(chan [2]interface{}, 10)
+	service.Watch("database.mysql.host", func(key string, oldVal interface{}, newVal interface{}) {
+		// 回调在锁外执行，可以直接读取配置
+		changes <- [2]interface{}{oldVal, service.Get(key)}
+	})
+	portChanged := false
+	cancel := service.Watch("database.mysql.port", func(key string, oldVal interface{}, newVal interface{}) {
+		portChanged = true
+	})
+	cancel()
+
+	// 连续写入多次只触发一次回调
+	for _, host := range []string{"10.0.0.1", "10.0.0.2", "10.0.0.3"} {
+		writeConfigFiles(t, folder, "testing", map[string]string{
+			"database.yml": "mysql:\n  host: " + host + "\n  port: 3307\n",
+		})
+	}
+	select {
+	case change := <-changes:
+		if change[0] != "127.0.0.1" || change[1] != "10.0.0.3" {
+			t.Fatalf("unexpected change: %v", change)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("watch callback not called")
+	}
+	select {
+	case change := <-changes:
+		t.Fatalf("unexpected second change: %v", change)
+	case <-time.After(3 * DefaultWatchDebounce):
+	}
+	if portChanged {
+		t.Fatal("canceled watch should not be called")
+	}
+}
+
+// waitFor 等待配置热更新完成
+func waitFor(t *testing.T, cond func() bool) {
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("timeout waiting for config reload")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestService_ReloadKeepLastGood(t *testing.T) {
+	folder := t.TempDir()
+	writeConfigFiles(t, folder, "testing", map[string]string{"app.yml": "name: goweb\n"})
+	instance, err := New(framework.NewGoWebContainer(), folder, "testing", map[string]string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	service := instance.(*Service)
+	defer service.Close()
+
+	// 保存了一半的文件解析失败，继续使用上一次的配置
+	writeConfigFiles(t, folder, "testing", map[string]string{"app.yml": "name: [goweb\n"})
+	waitFor(t, func() bool { return !service.ReloadStatus().IsOk() })
+	if service.GetString("app.name") != "goweb" {
+		t.Fatalf("expect last good config, got %v", service.Get("app.name"))
+	}
+
+	// 编辑器先写临时文件再重命名覆盖
+	tmp := filepath.Join(folder, "testing", "app.yml.tmp")
+	if err := os.WriteFile(tmp, []byte("name: goweb2\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(tmp, filepath.Join(folder, "testing", "app.yml")); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, func() bool { return service.GetString("app.name") == "goweb2" })
+	if status := service.ReloadStatus(); !status.IsOk() || status.LastReload.IsZero() {
+		t.Fatalf("unexpected reload status: %+v", status)
+	}
+}
+
+func TestService_ReloadKubernetesConfigMap(t *testing.T) {
+	folder := t.TempDir()
+	envFolder := filepath.Join(folder, "testing")
+	// 模拟 ConfigMap 的目录结构：app.yml -> ..data/app.yml，..data -> ..v1
+	writeConfigFiles(t, envFolder, "..v1", map[string]string{"app.yml": "name: v1\n"})
+	if err := os.Symlink("..v1", filepath.Join(envFolder, "..data")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(filepath.Join("..data", "app.yml"), filepath.Join(envFolder, "app.yml")); err != nil {
+		t.Fatal(err)
+	}
+	instance, err := New(framework.NewGoWebContainer(), folder, "testing", map[string]string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	service := instance.(*Service)
+	defer service.Close()
+	if service.GetString("app.name") != "v1" {
+		t.Fatalf("unexpected app.name: %v", service.Get("app.name"))
+	}
+
+	// 更新 ConfigMap：创建新的目录，再原子替换 ..data 软链接
+	writeConfigFiles(t, envFolder, "..v2", map[string]string{"app.yml": "name: v2\n"})
+	if err := os.Symlink("..v2", filepath.Join(envFolder, "..data_tmp")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(filepath.Join(envFolder, "..data_tmp"), filepath.Join(envFolder, "..data")); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, func() bool { return service.GetString("app.name") == "v2" })
+}
+
+func TestNew_Placeholders(t *testing.T) {
+	folder := t.TempDir()
+	writeConfigFiles(t, folder, "testing", map[string]string{
+		"app.yml": "name: env(APP_NAME, goweb)\nlabel: env(APP_LABEL, \"a b\")\nraw: $env(APP_NAME)\n" +
+			"# password: env(DB_PASSWORD)\n  #token: env(API_TOKEN)\n",
+		"database.yml": "mysql:\n  host: env(DB_HOST)\n  port: env(DB_PORT, 3306)\n" +
+			"  dsn: root@tcp(${database.mysql.host}:${database.mysql.port})/${app.name}\n" +
+			"  alias: ${database.mysql.port}\n  literal: $${database.mysql.host}\n",
+	})
+
+	instance, err := New(framework.NewGoWebContainer(), folder, "testing", map[string]string{"DB_HOST": "db.local"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	service := instance.(*Service)
+	defer service.Close()
+
+	expect := map[string]interface{}{
+		"app.name":               "goweb",
+		"app.label":              "a b",
+		"app.raw":                "env(APP_NAME)",
+		"database.mysql.host":    "db.local",
+		"database.mysql.port":    3306,
+		"database.mysql.dsn":     "root@tcp(db.local:3306)/goweb",
+		"database.mysql.alias":   3306,
+		"database.mysql.literal": "${database.mysql.host}",
+	}
+	for key, val := range expect {
+		if got := service.Get(key); got != val {
+			t.Errorf("%s: expect %v(%T), got %v(%T)", key, val, val, got, got)
+		}
+	}
+}
+
+func TestNew_SecretFilePlaceholder(t *testing.T) {
+	folder := t.TempDir()
+	secret := filepath.Join(folder, "db_password")
+	if err := os.WriteFile(secret, []byte("p@ss\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	_ = os.Setenv("TEST_CONFIG_DB_PASSWORD_FILE", secret)
+	defer os.Unsetenv("TEST_CONFIG_DB_PASSWORD_FILE")
+	writeConfigFiles(t, folder, "testing", map[string]string{
+		"database.yml": "password: env(TEST_CONFIG_DB_PASSWORD)\n",
+	})
+
+	// env(KEY) 可以使用 KEY_FILE 指向的密钥文件
+	container := framework.NewGoWebContainer()
+	if err := container.Bind(&env.Provider{Folder: folder}); err != nil {
+		t.Fatal(err)
+	}
+	instance, err := New(container, folder, "testing", map[string]string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	service := instance.(*Service)
+	defer service.Close()
+	if got := service.GetString("database.password"); got != "p@ss" {
+		t.Fatalf("expect secret from file, got %q", got)
+	}
+}
+
+func TestNew_UnresolvedPlaceholders(t *testing.T) {
+	folder := t.TempDir()
+	writeConfigFiles(t, folder, "testing", map[string]string{
+		"app.yml":      "name: env(APP_NAME)\n",
+		"database.yml": "a: ${database.b}\nb: ${database.a}\nc: ${cache.host}\n",
+	})
+
+	_, err := New(framework.NewGoWebContainer(), folder, "testing", map[string]string{})
+	if errors.Cause(err) != ErrUnresolvedPlaceholder {
+		t.Fatalf("expect unresolved placeholder error, got %v", err)
+	}
+	for _, item := range []string{"env(APP_NAME)", "${cache.host}", "circular reference"} {
+		if !strings.Contains(err.Error(), item) {
+			t.Errorf("expect %s in error: %v", item, err)
+		}
+	}
+}
+
+func TestNew_Encrypted(t *testing.T) {
+	folder := t.TempDir()
+	key, err := LoadEncryptKey(folder, map[string]string{EncryptKeyEnv: "secret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	encrypted, err := Encrypt(key, "p@ss: #1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeConfigFiles(t, folder, "testing", map[string]string{
+		"database.yml": "mysql:\n  user: root\n  password: " + encrypted + "\n",
+	})
+	// 密钥放在配置目录下的 .key 文件中
+	if err := os.WriteFile(filepath.Join(folder, EncryptKeyFile), []byte("secret\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	instance, err := New(framework.NewGoWebContainer(), folder, "testing", map[string]string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	service := instance.(*Service)
+	defer service.Close()
+	if got := service.GetString("database.mysql.password"); got != "p@ss: #1" {
+		t.Fatalf("unexpected password: %s", got)
+	}
+	if !service.IsEncrypted("database.mysql.password") || service.IsEncrypted("database.mysql.user") {
+		t.Fatal("unexpected encrypted keys")
+	}
+
+	// 密钥错误的时候无法解密
+	wrongKey, _ := LoadEncryptKey(folder, map[string]string{EncryptKeyEnv: "wrong"})
+	if _, err := Decrypt(wrongKey, encrypted); errors.Cause(err) != ErrDecryptFailed {
+		t.Fatalf("expect decrypt error with wrong key, got %v", err)
+	}
+
+	// 启动的时候密钥错误或者没有密钥直接报错，而不是忽略整个配置文件
+	_, err = New(framework.NewGoWebContainer(), folder, "testing", map[string]string{EncryptKeyEnv: "wrong"})
+	if errors.Cause(err) != ErrDecryptFailed || !strings.Contains(err.Error(), "database.yml") {
+		t.Fatalf("expect decrypt failed error, got %v", err)
+	}
+	if err = os.Remove(filepath.Join(folder, EncryptKeyFile)); err != nil {
+		t.Fatal(err)
+	}
+	_, err = New(framework.NewGoWebContainer(), folder, "testing", map[string]string{})
+	if errors.Cause(err) != ErrNoEncryptKey {
+		t.Fatalf("expect no encrypt key error, got %v", err)
+	}
+}
@@ -0,0 +1,272 @@
+package config
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ConfigSource 配置文件之外的配置来源，例如配置中心、键值存储，叠加在所有配置文件之上，环境变量和命令行参数之下
+// 多个来源按添加的顺序叠加，后添加的优先
+type ConfigSource interface {
+	// Name 来源的名字，作为配置的层名，例如 HTTP 来源的 URL
+	Name() string
+	// Load 加载配置，返回的 map 第一层 key 为配置名，和配置文件的文件名一样，例如 {"database": {"mysql": {...}}}
+	// changed 为 false 表示配置和上一次加载的相比没有变化，例如 HTTP 返回了 304
+	Load(ctx context.Context) (confMaps map[string]interface{}, changed bool, err error)
+}
+
+// PollingSource 需要定时拉取的配置来源
+type PollingSource interface {
+	ConfigSource
+	// Interval 拉取配置的间隔，小于等于 0 表示只在启动的时候加载一次
+	Interval() time.Duration
+}
+
+// HTTPSource 从 HTTP 接口拉取 yaml 或者 json 格式的配置，支持通过 ETag 判断配置是否变化
+type HTTPSource struct {
+	URL string
+	// Format 配置的格式，和配置文件的后缀一样，例如 yaml、json，为空的时候根据 Content-Type 或者 URL 的后缀判断
+	Format string
+	// Header 请求时带上的 Header，例如鉴权信息
+	Header http.Header
+	// PollInterval 拉取配置的间隔，小于等于 0 表示只在启动的时候加载一次
+	PollInterval time.Duration
+	// Client 为空的时候使用超时时间为 10 秒的 http.Client
+	Client *http.Client
+
+	etag string
+	lock sync.Mutex
+}
+
+var _ PollingSource = (*HTTPSource)(nil)
+
+// NewHTTPSource 创建 HTTP 配置来源
+func NewHTTPSource(url string, interval time.Duration) *HTTPSource {
+	return &HTTPSource{URL: url, PollInterval: interval}
+}
+
+func (s *HTTPSource) Name() string {
+	return s.URL
+}
+
+func (s *HTTPSource) Interval() time.Duration {
+	return s.PollInterval
+}
+
+func (s *HTTPSource) Load(ctx context.Context) (map[string]interface{}, bool, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.URL, nil)
+	if err != nil {
+		return nil, false, errors.WithStack(err)
+	}
+	for key, values := range s.Header {
+		for _, value := range values {
+			req.Header.Add(key, value)
+		}
+	}
+	if s.etag != "" {
+		req.Header.Set("If-None-Match", s.etag)
+	}
+	client := s.Client
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, false, errors.WithStack(err)
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode == http.StatusNotModified {
+		return nil, false, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, false, errors.Errorf("load config from %s: unexpected status %s", s.URL, resp.Status)
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, false, errors.WithStack(err)
+	}
+	_, decoder, err := getDecoder("remote." + s.format(resp.Header.Get("Content-Type")))
+	if err != nil {
+		return nil, false, err
+	}
+	confMaps, err := decoder(body)
+	if err != nil {
+		return nil, false, errors.Wrapf(err, "decode config from %s", s.URL)
+	}
+	s.etag = resp.Header.Get("ETag")
+	return confMaps, true, nil
+}
+
+// format 获取配置的格式，优先使用 Format，其次是 Content-Type，最后是 URL 的后缀，默认为 yaml
+func (s *HTTPSource) format(contentType string) string {
+	if s.Format != "" {
+		return s.Format
+	}
+	switch {
+	case strings.Contains(contentType, "json"):
+		return "json"
+	case strings.Contains(contentType, "yaml"):
+		return "yaml"
+	case strings.Contains(contentType, "toml"):
+		return "toml"
+	}
+	if ext := strings.TrimPrefix(path.Ext(strings.SplitN(s.URL, "?", 2)[0]), "."); ext != "" {
+		return ext
+	}
+	return "yaml"
+}
+
+// DirSource 从目录中读取配置，文件名为点分割的配置路径，文件内容为配置的值
+// 例如 database.mysql.password 文件的内容就是 database.mysql.password 的值，可以在本地代替 consul、etcd 这类键值存储
+// 也可以用来读取 Kubernetes 挂载的 Secret
+type DirSource struct {
+	Folder string
+	// PollInterval 重新读取目录的间隔，小于等于 0 表示只在启动的时候加载一次
+	PollInterval time.Duration
+}
+
+var _ PollingSource = (*DirSource)(nil)
+
+// NewDirSource 创建目录配置来源
+func NewDirSource(folder string, interval time.Duration) *DirSource {
+	return &DirSource{Folder: folder, PollInterval: interval}
+}
+
+func (s *DirSource) Name() string {
+	return s.Folder
+}
+
+func (s *DirSource) Interval() time.Duration {
+	return s.PollInterval
+}
+
+// Load 每次都返回 changed 为 true，配置服务会对比前后的配置，只有值变化了才通知订阅者
+func (s *DirSource) Load(ctx context.Context) (map[string]interface{}, bool, error) {
+	entries, err := os.ReadDir(s.Folder)
+	if err != nil {
+		return nil, false, errors.WithStack(err)
+	}
+	confMaps := make(map[string]interface{})
+	for _, entry := range entries {
+		key := entry.Name()
+		// 跳过隐藏文件，例如 Kubernetes 的 ..data
+		if strings.HasPrefix(key, ".") {
+			continue
+		}
+		info, err := os.Stat(filepath.Join(s.Folder, key))
+		if err != nil || info.IsDir() {
+			continue
+		}
+		content, err := ioutil.ReadFile(filepath.Join(s.Folder, key))
+		if err != nil {
+			return nil, false, errors.WithStack(err)
+		}
+		if err = setPath(confMaps, strings.Split(key, "."), strings.TrimRight(string(content), "\r\n")); err != nil {
+			return nil, false, errors.Wrapf(err, "load config from %s", s.Folder)
+		}
+	}
+	return confMaps, true, nil
+}
+
+// AddSource 添加一个配置来源，立即加载一次，加载失败返回错误
+// 实现了 PollingSource 的来源会按间隔定时拉取，拉取失败的时候保留上一次的配置，错误可以通过 ReloadStatus 获取
+func (s *Service) AddSource(source ConfigSource) error {
+	confMaps, _, err := source.Load(s.ctx)
+	if err != nil {
+		return err
+	}
+	layer := &configLayer{name: source.Name(), priority: prioritySource, files: make(map[string]*configFile)}
+	before := s.All()
+	if err = s.applySource(layer, confMaps); err != nil {
+		return err
+	}
+	s.subscribers.notify(before, s.All())
+
+	if polling, ok := source.(PollingSource); ok && polling.Interval() > 0 {
+		go s.pollSource(layer, polling)
+	}
+	return nil
+}
+
+// pollSource 定时拉取配置，服务关闭的时候退出
+func (s *Service) pollSource(layer *configLayer, source PollingSource) {
+	ticker := time.NewTicker(source.Interval())
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		confMaps, changed, err := source.Load(s.ctx)
+		if err == nil && !changed {
+			continue
+		}
+		before := s.All()
+		if err == nil {
+			err = s.applySource(layer, confMaps)
+		}
+		if err != nil {
+			// 服务已经关闭，不需要记录错误
+			if s.ctx.Err() != nil {
+				return
+			}
+			s.lock.Lock()
+			s.reloadErrors[layer.name] = err
+			s.lock.Unlock()
+			s.logReloadError(layer.name, err)
+			continue
+		}
+		s.lock.Lock()
+		s.reloadTime = time.Now()
+		s.lock.Unlock()
+		s.subscribers.notify(before, s.All())
+	}
+}
+
+// applySource 用配置来源加载的配置替换这一层的配置，并重新合并，新的来源会添加到最上层
+func (s *Service) applySource(layer *configLayer, confMaps map[string]interface{}) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	files := make(map[string]*configFile, len(confMaps))
+	for name, val := range confMaps {
+		tree, ok := copyValue(val).(map[string]interface{})
+		if !ok {
+			return errors.Errorf("config %s from %s should be a map", name, layer.name)
+		}
+		encrypted, err := s.decryptTree(name, tree)
+		if err != nil {
+			return err
+		}
+		files[name] = &configFile{fileName: layer.name, tree: tree, encrypted: encrypted}
+	}
+
+	s.addLayer(layer)
+	// 原来有现在没有的配置也需要重新合并
+	names := make(map[string]bool)
+	for name := range layer.files {
+		names[name] = true
+	}
+	for name := range files {
+		names[name] = true
+	}
+	layer.files = files
+	for name := range names {
+		s.mergeLayers(name)
+	}
+	delete(s.reloadErrors, layer.name)
+	return nil
+}
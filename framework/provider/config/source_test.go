@@ -0,0 +1,103 @@
+package config
+
+import (
+	"github.com/pkg/errors"
+	"github.com/wxsatellite/goweb/framework"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestHTTPSource(t *testing.T) {
+	var lock sync.Mutex
+	body, etag := "database:\n  mysql:\n    host: remote.v1\n", `"v1"`
+	var notModified int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		defer lock.Unlock()
+		if r.Header.Get("If-None-Match") == etag {
+			atomic.AddInt32(&notModified, 1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", etag)
+		w.Header().Set("Content-Type", "application/yaml")
+		_, _ = w.Write([]byte(body))
+	}))
+	defer server.Close()
+
+	folder := t.TempDir()
+	writeConfigFiles(t, folder, "testing", map[string]string{
+		"database.yml": "mysql:\n  host: 127.0.0.1\n  port: 3306\n",
+	})
+	source := NewHTTPSource(server.URL+"/config", 10*time.Millisecond)
+	instance, err := New(framework.NewGoWebContainer(), folder, "testing", map[string]string{}, []ConfigSource{source})
+	if err != nil {
+		t.Fatal(err)
+	}
+	service := instance.(*Service)
+	defer service.Close()
+
+	// 远程配置叠加在配置文件之上
+	if service.GetString("database.mysql.host") != "remote.v1" || service.GetInt("database.mysql.port") != 3306 {
+		t.Fatalf("unexpected config: %v", service.Get("database.mysql"))
+	}
+	if layer, _ := service.Source("database.mysql.host"); layer != source.Name() {
+		t.Fatalf("unexpected layer: %s", layer)
+	}
+	waitFor(t, func() bool { return atomic.LoadInt32(&notModified) > 0 })
+
+	changed := make(chan interface{}, 1)
+	service.Watch("database.mysql.host", func(key string, oldVal interface{}, newVal interface{}) {
+		changed <- newVal
+	})
+	lock.Lock()
+	body, etag = "database:\n  mysql:\n    host: remote.v2\n", `"v2"`
+	lock.Unlock()
+	select {
+	case val := <-changed:
+		if val != "remote.v2" {
+			t.Fatalf("unexpected new value: %v", val)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("remote config change not applied")
+	}
+
+	// 拉取失败的时候保留上一次的配置
+	server.Close()
+	waitFor(t, func() bool { return !service.ReloadStatus().IsOk() })
+	if service.GetString("database.mysql.host") != "remote.v2" {
+		t.Fatalf("expect last good config, got %v", service.Get("database.mysql.host"))
+	}
+}
+
+func TestDirSource(t *testing.T) {
+	folder := t.TempDir()
+	writeConfigFiles(t, folder, "testing", map[string]string{"app.yml": "name: goweb\n"})
+	secrets := filepath.Join(folder, "secrets")
+	writeConfigFiles(t, secrets, "", map[string]string{
+		"database.mysql.password": "p@ss\n",
+		".hidden":                 "ignored",
+	})
+
+	instance, err := New(framework.NewGoWebContainer(), folder, "testing", map[string]string{}, []ConfigSource{NewDirSource(secrets, 0)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	service := instance.(*Service)
+	defer service.Close()
+	if service.GetString("database.mysql.password") != "p@ss" || service.GetString("app.name") != "goweb" {
+		t.Fatalf("unexpected config: %v", service.All())
+	}
+
+	// 目录不存在的时候启动报错
+	_, err = New(framework.NewGoWebContainer(), folder, "testing", map[string]string{}, []ConfigSource{NewDirSource(filepath.Join(folder, "none"), 0)})
+	if !os.IsNotExist(errors.Cause(err)) {
+		t.Fatalf("expect not exist error, got %v", err)
+	}
+}
@@ -0,0 +1,73 @@
+package config
+
+import (
+	"log"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultWatchDebounce 配置文件修改之后等待的时间，编辑器保存的时候会连续触发多次写事件，合并成一次重新加载
+const DefaultWatchDebounce = 100 * time.Millisecond
+
+// subscriber 一个配置变化的订阅
+type subscriber struct {
+	key      string
+	callback WatchFunc
+}
+
+// subscribers 所有配置变化的订阅，零值可以直接使用
+type subscribers struct {
+	lock  sync.Mutex
+	seq   int
+	items map[int]*subscriber
+}
+
+// add 添加一个订阅，返回取消订阅的函数
+func (s *subscribers) add(key string, callback WatchFunc) (cancel func()) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.items == nil {
+		s.items = make(map[int]*subscriber)
+	}
+	s.seq++
+	id := s.seq
+	s.items[id] = &subscriber{key: key, callback: callback}
+	return func() {
+		s.lock.Lock()
+		defer s.lock.Unlock()
+		delete(s.items, id)
+	}
+}
+
+// notify 对比修改前后的配置，调用值发生变化的订阅
+// before 和 after 是配置的副本，回调在锁外执行，回调中可以读取配置或者添加新的订阅
+func (s *subscribers) notify(before map[string]interface{}, after map[string]interface{}) {
+	s.lock.Lock()
+	items := make([]*subscriber, 0, len(s.items))
+	for _, item := range s.items {
+		items = append(items, item)
+	}
+	s.lock.Unlock()
+
+	for _, item := range items {
+		path := strings.Split(item.key, ".")
+		oldVal := searchMap(before, path)
+		newVal := searchMap(after, path)
+		if reflect.DeepEqual(oldVal, newVal) {
+			continue
+		}
+		item.call(oldVal, newVal)
+	}
+}
+
+// call 调用回调，回调 panic 不能影响其他订阅以及配置文件的监控
+func (s *subscriber) call(oldVal interface{}, newVal interface{}) {
+	defer func() {
+		if err := recover(); err != nil {
+			log.Println("配置变化回调错误：", s.key, err)
+		}
+	}()
+	s.callback(s.key, oldVal, newVal)
+}
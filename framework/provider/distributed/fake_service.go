@@ -0,0 +1,44 @@
+package distributed
+
+import (
+	"sync"
+	"time"
+)
+
+// FakeService 内存中的分布式选择器，用于测试
+// 默认每次都选中调用方自己，可以通过 SetSelected 模拟被其他节点抢占
+type FakeService struct {
+	selected map[string]string // 服务名对应被选中的 appId
+	calls    []string          // 按照调用顺序记录服务名
+	lock     sync.Mutex
+}
+
+var _ Distributed = (*FakeService)(nil)
+
+func NewFakeService() *FakeService {
+	return &FakeService{selected: make(map[string]string)}
+}
+
+// SetSelected 设置某个服务固定选中的 appId
+func (s *FakeService) SetSelected(serviceName string, appId string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.selected[serviceName] = appId
+}
+
+// Calls 返回所有调用过 Select 的服务名
+func (s *FakeService) Calls() []string {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return append([]string{}, s.calls...)
+}
+
+func (s *FakeService) Select(serviceName string, appId string, holdTime time.Duration) (selectedAppId string, err error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.calls = append(s.calls, serviceName)
+	if selected, ok := s.selected[serviceName]; ok {
+		return selected, nil
+	}
+	return appId, nil
+}
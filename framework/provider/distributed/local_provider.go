@@ -2,6 +2,7 @@ package distributed
 
 import (
 	"github.com/wxsatellite/goweb/framework"
+	"github.com/wxsatellite/goweb/framework/provider/health"
 )
 
 type LocalProvider struct {
@@ -23,6 +24,11 @@ func (p *LocalProvider) IsDefer() bool {
 	return false
 }
 
+// Tags 分布式锁参与就绪检查，检查文件锁所在的目录是否可用
+func (p *LocalProvider) Tags() []string {
+	return []string{health.CheckerTag}
+}
+
 func (p *LocalProvider) Name() string {
 	return Key
 }
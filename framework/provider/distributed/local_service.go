@@ -1,6 +1,7 @@
 package distributed
 
 import (
+	"context"
 	"errors"
 	"github.com/wxsatellite/goweb/framework"
 	"github.com/wxsatellite/goweb/framework/provider/app"
@@ -30,7 +31,7 @@ func NewLocalProvider(params ...interface{}) (interface{}, error) {
 }
 
 func (s *LocalService) Select(serviceName string, appId string, holdTime time.Duration) (selectedAppId string, err error) {
-	appService := s.container.MustMake(Key).(app.App)
+	appService := s.container.MustMake(app.Key).(app.App)
 
 	runtimeFolder := appService.RuntimeFolder()
 
@@ -78,3 +79,14 @@ func (s *LocalService) Select(serviceName string, appId string, holdTime time.Du
 	}
 	return appId, nil
 }
+
+// HealthCheck 就绪检查：文件锁所在的运行时目录是否可以创建文件
+func (s *LocalService) HealthCheck(ctx context.Context) error {
+	appService := s.container.MustMake(app.Key).(app.App)
+	f, err := ioutil.TempFile(appService.RuntimeFolder(), ".health")
+	if err != nil {
+		return err
+	}
+	_ = f.Close()
+	return os.Remove(f.Name())
+}
@@ -11,6 +11,11 @@ const (
 	Development = "development"
 
 	Key = "goweb:env"
+
+	// SourceDefault 框架设置的默认值，例如没有设置 APP_ENV 的时候为开发环境
+	SourceDefault = "default"
+	// SourceEnviron 运行时设置的环境变量
+	SourceEnviron = "environment"
 )
 
 /**
@@ -26,8 +31,14 @@ type Env interface {
 	AppEnv() string
 	// IsExist 判断一个环境变量是否有被设置
 	IsExist(key string) bool
-	// Get 获取某个环境变量，如果没有设置，返回""
+	// Get 获取某个环境变量，如果没有设置，返回""，没有设置 KEY 但设置了 KEY_FILE 的时候返回 KEY_FILE 指向的密钥文件的内容
 	Get(string) string
-	// All 获取所有的环境变量，.env 和运行环境变量融合后结果
+	// All 获取所有的环境变量，.env 和运行环境变量融合后结果，密钥文件中的环境变量获取过之后才会包含在里面
 	All() map[string]string
+	// Source 获取环境变量的来源，来自 .env 文件或者密钥文件的时候为文件路径，运行时设置的为 SourceEnviron，没有设置的时候返回 ""
+	Source(key string) string
+	// IsSecret 环境变量是否是从 KEY_FILE 指向的密钥文件中读取的，密钥不会在 goweb env list 中输出
+	IsSecret(key string) bool
+	// Load 把环境变量解析到结构体中，val 为结构体指针，字段标签的用法参考 Decode
+	Load(val interface{}) error
 }
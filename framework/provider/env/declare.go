@@ -0,0 +1,130 @@
+package env
+
+import (
+	"github.com/pkg/errors"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// VarType 环境变量的类型，用于校验环境变量的值
+type VarType string
+
+const (
+	TypeString VarType = "string"
+	TypeInt    VarType = "int"
+	TypeBool   VarType = "bool"
+	TypeURL    VarType = "url"
+	// TypeEnum 值必须是 Var.Enum 中的一个
+	TypeEnum VarType = "enum"
+)
+
+// ErrMissing 必须的环境变量没有设置
+var ErrMissing = errors.New("required environment variable is not set")
+
+// Var 声明一个应用或者服务提供者用到的环境变量
+type Var struct {
+	Name        string
+	Description string
+	// Required 为 true 的时候环境变量必须设置，goweb env check 会报错
+	Required bool
+	// Type 为空的时候不校验值
+	Type VarType
+	// Enum Type 为 TypeEnum 的时候可选的值
+	Enum []string
+}
+
+// Validate 校验环境变量的值是否符合声明的类型，value 为空的时候不校验
+func (v Var) Validate(value string) error {
+	if value == "" {
+		return nil
+	}
+	switch v.Type {
+	case "", TypeString:
+		return nil
+	case TypeInt:
+		if _, err := strconv.Atoi(value); err != nil {
+			return errors.Errorf("%q is not an int", value)
+		}
+	case TypeBool:
+		if _, err := strconv.ParseBool(value); err != nil {
+			return errors.Errorf("%q is not a bool", value)
+		}
+	case TypeURL:
+		u, err := url.Parse(value)
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			return errors.Errorf("%q is not an absolute url", value)
+		}
+	case TypeEnum:
+		for _, item := range v.Enum {
+			if item == value {
+				return nil
+			}
+		}
+		return errors.Errorf("%q is not one of %s", value, strings.Join(v.Enum, ", "))
+	default:
+		return errors.Errorf("unknown type %s", v.Type)
+	}
+	return nil
+}
+
+var (
+	declared     = make(map[string]Var)
+	declaredLock sync.RWMutex
+)
+
+// Declare 声明用到的环境变量，一般在服务提供者的 Register 或者应用启动的时候调用，重复声明的时候后声明的覆盖先声明的
+// goweb env check 会检查所有声明的环境变量
+func Declare(vars ...Var) {
+	declaredLock.Lock()
+	defer declaredLock.Unlock()
+	for _, v := range vars {
+		declared[v.Name] = v
+	}
+}
+
+// Declared 获取所有声明的环境变量，按名字排序
+func Declared() []Var {
+	declaredLock.RLock()
+	defer declaredLock.RUnlock()
+	vars := make([]Var, 0, len(declared))
+	for _, v := range declared {
+		vars = append(vars, v)
+	}
+	sort.Slice(vars, func(i, j int) bool {
+		return vars[i].Name < vars[j].Name
+	})
+	return vars
+}
+
+// CheckResult 一个声明的环境变量的检查结果
+type CheckResult struct {
+	Var    Var
+	Value  string
+	Source string // 值的来源，参考 Env.Source
+	Err    error  // 检查失败的原因，成功为 nil
+}
+
+// Check 检查所有声明的环境变量，必须的环境变量没有设置的时候 Err 为 ErrMissing
+func Check(e Env) []CheckResult {
+	vars := Declared()
+	results := make([]CheckResult, 0, len(vars))
+	for _, v := range vars {
+		result := CheckResult{Var: v, Value: e.Get(v.Name), Source: e.Source(v.Name)}
+		if result.Value == "" && v.Required {
+			result.Err = ErrMissing
+		} else {
+			result.Err = v.Validate(result.Value)
+		}
+		// 密钥文件读取失败的时候，报告读取失败的原因
+		if se, ok := e.(interface{ SecretError(key string) error }); ok && result.Value == "" {
+			if err := se.SecretError(v.Name); err != nil {
+				result.Err = err
+			}
+		}
+		results = append(results, result)
+	}
+	return results
+}
@@ -0,0 +1,31 @@
+package env
+
+import "testing"
+
+func TestCheck(t *testing.T) {
+	Declare(
+		Var{Name: "TEST_DB_HOST", Required: true},
+		Var{Name: "TEST_DB_PORT", Type: TypeInt},
+		Var{Name: "TEST_API", Type: TypeURL},
+		Var{Name: "TEST_MODE", Type: TypeEnum, Enum: []string{"a", "b"}},
+		Var{Name: "TEST_OPTIONAL"},
+	)
+	service := NewFakeService(map[string]string{"TEST_DB_PORT": "abc", "TEST_API": "https://example.com", "TEST_MODE": "c"})
+
+	results := make(map[string]CheckResult)
+	for _, result := range Check(service) {
+		results[result.Var.Name] = result
+	}
+	if results["TEST_DB_HOST"].Err != ErrMissing {
+		t.Errorf("expect missing, got %v", results["TEST_DB_HOST"].Err)
+	}
+	if results["TEST_DB_PORT"].Err == nil || results["TEST_MODE"].Err == nil {
+		t.Errorf("expect invalid values, got %v", results)
+	}
+	if results["TEST_API"].Err != nil || results["TEST_OPTIONAL"].Err != nil {
+		t.Errorf("unexpected errors: %v", results)
+	}
+	if results["TEST_API"].Source != SourceEnviron || results["TEST_OPTIONAL"].Source != "" {
+		t.Errorf("unexpected sources: %v", results)
+	}
+}
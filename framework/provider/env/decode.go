@@ -0,0 +1,122 @@
+package env
+
+import (
+	"github.com/pkg/errors"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Decode 把环境变量解析到结构体中，val 必须是结构体指针，通过字段的标签指定环境变量：
+// env:"DB_HOST" 环境变量的名字，env:"DB_HOST,required" 表示必须设置，没有 env 标签的字段忽略
+// default:"3306" 环境变量没有设置或者为空的时候使用的默认值
+// separator:";" 切片的分隔符，默认为逗号
+// 支持字符串、布尔值、数字、time.Duration 以及它们的切片，time.Duration 使用 "5s" 这样的格式
+// 嵌套的结构体字段会继续解析，env 标签作为里面所有环境变量的前缀，例如 env:"DB_" 加上 HOST 为 DB_HOST
+// 所有字段的错误合并在一起返回，必须的环境变量没有设置的错误为 ErrMissing
+func Decode(e Env, val interface{}) error {
+	v := reflect.ValueOf(val)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return errors.Errorf("decode env: expect a struct pointer, got %T", val)
+	}
+	var errs []string
+	decodeStruct(e, v.Elem(), "", &errs)
+	if len(errs) > 0 {
+		return errors.New("decode env: " + strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+func decodeStruct(e Env, v reflect.Value, prefix string, errs *[]string) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag, ok := field.Tag.Lookup("env")
+		if !ok || field.PkgPath != "" {
+			continue
+		}
+		options := strings.Split(tag, ",")
+		name := prefix + strings.TrimSpace(options[0])
+
+		// 嵌套的结构体，env 标签作为前缀
+		if field.Type.Kind() == reflect.Struct {
+			decodeStruct(e, v.Field(i), name, errs)
+			continue
+		}
+
+		value := e.Get(name)
+		if value == "" {
+			value = field.Tag.Get("default")
+		}
+		if value == "" {
+			for _, option := range options[1:] {
+				if strings.TrimSpace(option) == "required" {
+					*errs = append(*errs, name+": "+ErrMissing.Error())
+				}
+			}
+			continue
+		}
+		separator := field.Tag.Get("separator")
+		if separator == "" {
+			separator = ","
+		}
+		if err := setValue(v.Field(i), value, separator); err != nil {
+			*errs = append(*errs, name+": "+err.Error())
+		}
+	}
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// setValue 把字符串转换为字段的类型
+func setValue(v reflect.Value, value string, separator string) error {
+	if v.Type() == durationType {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return errors.Errorf("%q is not a duration", value)
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return errors.Errorf("%q is not a bool", value)
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, v.Type().Bits())
+		if err != nil {
+			return errors.Errorf("%q is not a %s", value, v.Type())
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(value, 10, v.Type().Bits())
+		if err != nil {
+			return errors.Errorf("%q is not a %s", value, v.Type())
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(value, v.Type().Bits())
+		if err != nil {
+			return errors.Errorf("%q is not a %s", value, v.Type())
+		}
+		v.SetFloat(f)
+	case reflect.Slice:
+		items := strings.Split(value, separator)
+		slice := reflect.MakeSlice(v.Type(), len(items), len(items))
+		for i, item := range items {
+			if err := setValue(slice.Index(i), strings.TrimSpace(item), separator); err != nil {
+				return err
+			}
+		}
+		v.Set(slice)
+	default:
+		return errors.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package env
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testDBConfig struct {
+	Host    string        `env:"HOST,required"`
+	Port    int           `env:"PORT" default:"3306"`
+	Debug   bool          `env:"DEBUG"`
+	Timeout time.Duration `env:"TIMEOUT" default:"5s"`
+	Hosts   []string      `env:"HOSTS" separator:";"`
+	Ports   []uint16      `env:"PORTS"`
+}
+
+type testAppConfig struct {
+	Name   string       `env:"APP_NAME"`
+	DB     testDBConfig `env:"DB_"`
+	Ignore string
+}
+
+func TestDecode(t *testing.T) {
+	service := NewFakeService(map[string]string{
+		"APP_NAME":   "goweb",
+		"DB_HOST":    "127.0.0.1",
+		"DB_DEBUG":   "true",
+		"DB_TIMEOUT": "1m",
+		"DB_HOSTS":   "a; b",
+		"DB_PORTS":   "1,2",
+		"Ignore":     "x",
+	})
+	var cfg testAppConfig
+	if err := service.Load(&cfg); err != nil {
+		t.Fatal(err)
+	}
+	expect := testAppConfig{Name: "goweb", DB: testDBConfig{
+		Host: "127.0.0.1", Port: 3306, Debug: true, Timeout: time.Minute, Hosts: []string{"a", "b"}, Ports: []uint16{1, 2},
+	}}
+	if !reflect.DeepEqual(cfg, expect) {
+		t.Fatalf("unexpected config: %+v", cfg)
+	}
+
+	service = NewFakeService(map[string]string{"DB_PORT": "abc"})
+	err := Decode(service, &cfg)
+	if err == nil || !strings.Contains(err.Error(), "DB_HOST: "+ErrMissing.Error()) || !strings.Contains(err.Error(), "DB_PORT") {
+		t.Fatalf("expect missing and invalid errors, got %v", err)
+	}
+	if err = Decode(service, cfg); err == nil {
+		t.Fatal("expect error for non pointer")
+	}
+}
@@ -0,0 +1,278 @@
+package env
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+// ParseError .env 文件格式错误，Line 为出错的行号，从 1 开始
+type ParseError struct {
+	File string
+	Line int
+	Msg  string
+}
+
+func (e *ParseError) Error() string {
+	if e.File == "" {
+		return fmt.Sprintf("line %d: %s", e.Line, e.Msg)
+	}
+	return fmt.Sprintf("%s:%d: %s", e.File, e.Line, e.Msg)
+}
+
+// Parse 解析 .env 格式的内容，# 开头的行以及值后面空格加 # 开头的内容是注释，export 前缀会被忽略
+// 单引号中的内容原样保留，双引号中支持 \n \t \" \\ \$ 转义，引号中的值可以跨行
+// 值中可以使用 ${VAR}、${VAR:-default} 以及 $VAR 引用其他变量，单引号中不替换
+// 引用的变量先从运行环境变量中查找，再从前面已经解析过的变量中查找
+func Parse(r io.Reader) (map[string]string, error) {
+	content, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	envs := make(map[string]string)
+	err = parseDotenv(string(content), func(key string) (string, bool) {
+		if val, ok := os.LookupEnv(key); ok {
+			return val, true
+		}
+		val, ok := envs[key]
+		return val, ok
+	}, func(key string, val string) {
+		envs[key] = val
+	})
+	return envs, err
+}
+
+// parseDotenv 逐个解析 .env 中的变量，lookup 用于替换 ${VAR}，每解析出一个变量调用一次 set
+func parseDotenv(content string, lookup func(string) (string, bool), set func(key string, val string)) error {
+	p := &dotenvParser{src: []rune(strings.ReplaceAll(content, "\r\n", "\n")), line: 1, lookup: lookup}
+	for {
+		p.skipBlank()
+		if p.eof() {
+			return nil
+		}
+		if p.peek() == '#' {
+			p.skipLine()
+			continue
+		}
+		key, err := p.key()
+		if err != nil {
+			return err
+		}
+		val, err := p.value()
+		if err != nil {
+			return err
+		}
+		set(key, val)
+	}
+}
+
+type dotenvParser struct {
+	src    []rune
+	pos    int
+	line   int
+	lookup func(string) (string, bool)
+}
+
+func (p *dotenvParser) eof() bool {
+	return p.pos >= len(p.src)
+}
+
+func (p *dotenvParser) peek() rune {
+	return p.src[p.pos]
+}
+
+func (p *dotenvParser) next() rune {
+	r := p.src[p.pos]
+	p.pos++
+	if r == '\n' {
+		p.line++
+	}
+	return r
+}
+
+func (p *dotenvParser) errorf(format string, args ...interface{}) error {
+	return &ParseError{Line: p.line, Msg: fmt.Sprintf(format, args...)}
+}
+
+// skipBlank 跳过空白字符以及空行
+func (p *dotenvParser) skipBlank() {
+	for !p.eof() && strings.ContainsRune(" \t\n", p.peek()) {
+		p.next()
+	}
+}
+
+// skipSpace 跳过同一行内的空白字符
+func (p *dotenvParser) skipSpace() {
+	for !p.eof() && (p.peek() == ' ' || p.peek() == '\t') {
+		p.next()
+	}
+}
+
+func (p *dotenvParser) skipLine() {
+	for !p.eof() && p.next() != '\n' {
+	}
+}
+
+// key 解析变量名以及后面的 =
+func (p *dotenvParser) key() (string, error) {
+	start := p.pos
+	for !p.eof() && isKeyRune(p.peek()) {
+		p.next()
+	}
+	key := string(p.src[start:p.pos])
+	// export KEY=value
+	if key == "export" && !p.eof() && (p.peek() == ' ' || p.peek() == '\t') {
+		p.skipSpace()
+		return p.key()
+	}
+	if key == "" {
+		return "", p.errorf("invalid variable name")
+	}
+	p.skipSpace()
+	if p.eof() || p.peek() != '=' {
+		return "", p.errorf("expected '=' after %s", key)
+	}
+	p.next()
+	p.skipSpace()
+	return key, nil
+}
+
+func isKeyRune(r rune) bool {
+	return r == '_' || r == '.' || r == '-' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9')
+}
+
+// value 解析 = 后面的值，以及值后面的行尾注释
+func (p *dotenvParser) value() (string, error) {
+	if p.eof() || p.peek() == '\n' {
+		return "", nil
+	}
+	var val string
+	var err error
+	switch p.peek() {
+	case '\'':
+		val, err = p.quoted('\'')
+	case '"':
+		val, err = p.quoted('"')
+	default:
+		return p.unquoted()
+	}
+	if err != nil {
+		return "", err
+	}
+	// 引号之后只能有注释
+	p.skipSpace()
+	if !p.eof() && p.peek() != '\n' && p.peek() != '#' {
+		return "", p.errorf("unexpected character %q after quoted value", p.peek())
+	}
+	p.skipLine()
+	return val, nil
+}
+
+func (p *dotenvParser) unquoted() (string, error) {
+	start := p.pos
+	end := p.pos
+	for !p.eof() && p.peek() != '\n' {
+		// 空格后面的 # 是注释，KEY=a#b 中的 # 是值的一部分
+		if p.peek() == '#' && p.pos > start && (p.src[p.pos-1] == ' ' || p.src[p.pos-1] == '\t') {
+			p.skipLine()
+			break
+		}
+		p.next()
+		end = p.pos
+	}
+	return p.expand(strings.TrimSpace(string(p.src[start:end])))
+}
+
+func (p *dotenvParser) quoted(quote rune) (string, error) {
+	line := p.line
+	p.next()
+	var b strings.Builder
+	for {
+		if p.eof() {
+			return "", &ParseError{Line: line, Msg: fmt.Sprintf("unterminated quoted value, missing %c", quote)}
+		}
+		r := p.next()
+		if r == quote {
+			break
+		}
+		if r != '\\' || quote == '\'' || p.eof() {
+			b.WriteRune(r)
+			continue
+		}
+		escaped := p.next()
+		switch escaped {
+		case 'n':
+			b.WriteRune('\n')
+		case 'r':
+			b.WriteRune('\r')
+		case 't':
+			b.WriteRune('\t')
+		case '"', '\\':
+			b.WriteRune(escaped)
+		case '$':
+			// 转义的 $ 不参与变量替换，先用占位字符保存
+			b.WriteRune(escapedDollar)
+		default:
+			b.WriteRune('\\')
+			b.WriteRune(escaped)
+		}
+	}
+	if quote == '\'' {
+		return b.String(), nil
+	}
+	return p.expand(b.String())
+}
+
+// escapedDollar 双引号中 \$ 的临时替代字符，变量替换完成之后再换回 $
+const escapedDollar = '\uFFFF'
+
+// expand 替换值中的 ${VAR}、${VAR:-default}、$VAR
+func (p *dotenvParser) expand(val string) (string, error) {
+	var b strings.Builder
+	src := []rune(val)
+	for i := 0; i < len(src); i++ {
+		if src[i] == escapedDollar {
+			b.WriteRune('$')
+			continue
+		}
+		if src[i] != '$' || i+1 >= len(src) {
+			b.WriteRune(src[i])
+			continue
+		}
+		if src[i+1] == '{' {
+			end := i + 2
+			for end < len(src) && src[end] != '}' {
+				end++
+			}
+			if end >= len(src) {
+				return "", p.errorf("unterminated variable reference in %q", val)
+			}
+			name := string(src[i+2 : end])
+			def := ""
+			if index := strings.Index(name, ":-"); index != -1 {
+				name, def = name[:index], name[index+2:]
+			}
+			if v, ok := p.lookup(name); ok && v != "" {
+				b.WriteString(v)
+			} else {
+				b.WriteString(def)
+			}
+			i = end
+			continue
+		}
+		end := i + 1
+		for end < len(src) && (src[end] == '_' || (src[end] >= 'a' && src[end] <= 'z') || (src[end] >= 'A' && src[end] <= 'Z') || (src[end] >= '0' && src[end] <= '9')) {
+			end++
+		}
+		if end == i+1 {
+			b.WriteRune(src[i])
+			continue
+		}
+		v, _ := p.lookup(string(src[i+1 : end]))
+		b.WriteString(v)
+		i = end - 1
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,97 @@
+package env
+
+import (
+	"github.com/wxsatellite/goweb/framework"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	content := `# comment
+export APP_NAME=goweb
+DSN=user:pw@tcp(127.0.0.1:3306)/db?charset=utf8&parseTime=true
+EMPTY=
+INLINE=value # comment
+HASH=a#b
+SINGLE='raw ${APP_NAME} \n'
+DOUBLE="hello ${APP_NAME}\tand \$HOME"
+MULTI="line1
+line2"
+DEFAULT=${NOT_SET_ANYWHERE:-fallback}
+SHORT=$APP_NAME/bin
+`
+	envs, err := Parse(strings.NewReader(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expects := map[string]string{
+		"APP_NAME": "goweb",
+		"DSN":      "user:pw@tcp(127.0.0.1:3306)/db?charset=utf8&parseTime=true",
+		"EMPTY":    "",
+		"INLINE":   "value",
+		"HASH":     "a#b",
+		"SINGLE":   `raw ${APP_NAME} \n`,
+		"DOUBLE":   "hello goweb\tand $HOME",
+		"MULTI":    "line1\nline2",
+		"DEFAULT":  "fallback",
+		"SHORT":    "goweb/bin",
+	}
+	for key, expect := range expects {
+		if envs[key] != expect {
+			t.Errorf("%s: expect %q, got %q", key, expect, envs[key])
+		}
+	}
+}
+
+func TestParseError(t *testing.T) {
+	cases := map[string]int{
+		"A=1\nB=2\nINVALID\n":     3,
+		"A=1\nB=\"unterminated\n": 2,
+		"A='x' y\n":               1,
+	}
+	for content, line := range cases {
+		_, err := Parse(strings.NewReader(content))
+		perr, ok := err.(*ParseError)
+		if !ok || perr.Line != line {
+			t.Errorf("%q: expect error at line %d, got %v", content, line, err)
+		}
+	}
+}
+
+func TestLayers(t *testing.T) {
+	folder := t.TempDir()
+	files := map[string]string{
+		".env":         "APP_ENV=testing\nDB_HOST=localhost\nDB_PORT=3306\nDB_USER=root\n",
+		".env.testing": "DB_HOST=testing.db\nDB_ADDR=${DB_HOST}:${DB_PORT}\n",
+		".env.local":   "DB_USER=me\n",
+	}
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(folder, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	_ = os.Setenv("DB_PORT", "3307")
+	defer os.Unsetenv("DB_PORT")
+	instance, err := New(framework.NewGoWebContainer(), folder)
+	if err != nil {
+		t.Fatal(err)
+	}
+	service := instance.(*Service)
+	expects := map[string]string{"DB_HOST": "testing.db", "DB_PORT": "3307", "DB_USER": "me", "DB_ADDR": "testing.db:3307"}
+	for key, expect := range expects {
+		if service.Get(key) != expect {
+			t.Errorf("%s: expect %q, got %q", key, expect, service.Get(key))
+		}
+	}
+
+	if err = ioutil.WriteFile(filepath.Join(folder, ".env.local"), []byte("OK=1\nBROKEN\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	_, err = New(framework.NewGoWebContainer(), folder)
+	if err == nil || !strings.Contains(err.Error(), ".env.local:2") {
+		t.Fatalf("expect line numbered error, got %v", err)
+	}
+}
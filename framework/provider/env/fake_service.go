@@ -0,0 +1,71 @@
+package env
+
+import "sync"
+
+// FakeService 内存中的环境变量服务，用于测试，不会读取 .env 文件和运行环境变量
+type FakeService struct {
+	envs map[string]string
+	lock sync.RWMutex
+}
+
+var _ Env = (*FakeService)(nil)
+
+// NewFakeService 创建内存环境变量服务，没有设置 APP_ENV 的时候默认为开发环境
+func NewFakeService(envs map[string]string) *FakeService {
+	all := map[string]string{"APP_ENV": Development}
+	for key, val := range envs {
+		all[key] = val
+	}
+	return &FakeService{envs: all}
+}
+
+// Set 设置一个环境变量
+func (s *FakeService) Set(key string, val string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.envs[key] = val
+}
+
+func (s *FakeService) AppEnv() string {
+	return s.Get("APP_ENV")
+}
+
+func (s *FakeService) IsExist(key string) (ok bool) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	_, ok = s.envs[key]
+	return
+}
+
+func (s *FakeService) Get(key string) string {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return s.envs[key]
+}
+
+func (s *FakeService) All() map[string]string {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	all := make(map[string]string, len(s.envs))
+	for key, val := range s.envs {
+		all[key] = val
+	}
+	return all
+}
+
+// Source 内存中的环境变量都当作运行时设置的环境变量
+func (s *FakeService) Source(key string) string {
+	if !s.IsExist(key) {
+		return ""
+	}
+	return SourceEnviron
+}
+
+// IsSecret 内存中的环境变量都不是密钥
+func (s *FakeService) IsSecret(key string) bool {
+	return false
+}
+
+func (s *FakeService) Load(val interface{}) error {
+	return Decode(s, val)
+}
@@ -7,6 +7,18 @@ import (
 
 type Provider struct {
 	Folder string
+	// SecretFileSuffix 密钥文件环境变量的后缀，为空的时候使用 DefaultSecretFileSuffix
+	SecretFileSuffix string
+	// DisableSecretFiles 为 true 的时候不读取 KEY_FILE 指向的密钥文件
+	DisableSecretFiles bool
+}
+
+// Depends 没有指定 .env 所在目录的时候，需要 app 服务获取基础路径
+func (p *Provider) Depends() []string {
+	if p.Folder != "" {
+		return nil
+	}
+	return []string{app.Key}
 }
 
 func (p *Provider) Boot(container framework.Container) (err error) {
@@ -20,7 +32,14 @@ func (p *Provider) Boot(container framework.Container) (err error) {
 }
 
 func (p *Provider) Params(container framework.Container) []interface{} {
-	return []interface{}{container, p.Folder}
+	suffix := p.SecretFileSuffix
+	if suffix == "" {
+		suffix = DefaultSecretFileSuffix
+	}
+	if p.DisableSecretFiles {
+		suffix = ""
+	}
+	return []interface{}{container, p.Folder, suffix}
 }
 
 func (p *Provider) Name() string {
@@ -32,5 +51,6 @@ func (p *Provider) IsDefer() bool {
 }
 
 func (p *Provider) Register(container framework.Container) framework.NewInstance {
+	Declare(Var{Name: "APP_ENV", Description: "当前运行的环境，建议为 development/testing/production"})
 	return New
 }
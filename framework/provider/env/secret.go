@@ -0,0 +1,62 @@
+package env
+
+import (
+	"github.com/pkg/errors"
+	"io/ioutil"
+	"log"
+	"strings"
+)
+
+// DefaultSecretFileSuffix Docker 和 Kubernetes 中常用的密钥文件约定，例如 DB_PASSWORD_FILE=/run/secrets/db_password
+// 没有设置 DB_PASSWORD 的时候，DB_PASSWORD 的值为文件的内容，去掉首尾的空白字符
+// 密钥文件在第一次获取 DB_PASSWORD 的时候才读取，所以 APP_LOG_FILE、SSL_CERT_FILE 这类本身就是路径的环境变量，
+// 只要没有获取 APP_LOG、SSL_CERT 就不会被当作密钥读取
+const DefaultSecretFileSuffix = "_FILE"
+
+// resolveSecret key 没有设置并且 KEY_FILE 设置了的时候，读取密钥文件作为 key 的值，每个 key 只读取一次
+// 密钥文件读取失败不影响获取其他环境变量，错误通过 SecretError 和 goweb env check 获取
+func (s *Service) resolveSecret(key string) {
+	if s.secretFileSuffix == "" {
+		return
+	}
+	s.lock.RLock()
+	_, exist := s.envs[key]
+	file := s.envs[key+s.secretFileSuffix]
+	tried := s.secretTried[key]
+	s.lock.RUnlock()
+	if exist || tried || file == "" {
+		return
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	// 其他 goroutine 可能已经读取过了
+	if _, exist = s.envs[key]; exist || s.secretTried[key] {
+		return
+	}
+	s.secretTried[key] = true
+	content, err := ioutil.ReadFile(file)
+	if err != nil {
+		s.secretErrors[key] = errors.Wrapf(err, "read secret file of %s", key)
+		log.Println("读取密钥文件失败：", s.secretErrors[key])
+		return
+	}
+	s.envs[key] = strings.TrimSpace(string(content))
+	s.sources[key] = file
+	s.secrets[key] = true
+}
+
+func (s *Service) IsSecret(key string) bool {
+	s.resolveSecret(key)
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return s.secrets[key]
+}
+
+// SecretError 获取环境变量对应的密钥文件读取失败的原因，没有失败返回 nil
+func (s *Service) SecretError(key string) error {
+	s.resolveSecret(key)
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return s.secretErrors[key]
+}
@@ -0,0 +1,78 @@
+package env
+
+import (
+	"github.com/wxsatellite/goweb/framework"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSecretFiles(t *testing.T) {
+	folder := t.TempDir()
+	secret := filepath.Join(folder, "db_password")
+	if err := ioutil.WriteFile(secret, []byte("p@ss\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	dotenv := "TEST_DB_PASSWORD_FILE=" + secret + "\nTEST_DB_USER=root\nTEST_DB_USER_FILE=" + secret + "\n" +
+		"TEST_APP_LOG_FILE=" + filepath.Join(folder, "none") + "\n"
+	if err := ioutil.WriteFile(filepath.Join(folder, ".env"), []byte(dotenv), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// 路径不存在的 KEY_FILE 不影响启动
+	instance, err := New(framework.NewGoWebContainer(), folder)
+	if err != nil {
+		t.Fatal(err)
+	}
+	service := instance.(*Service)
+	// 获取之前不会读取密钥文件
+	if _, ok := service.All()["TEST_DB_PASSWORD"]; ok {
+		t.Fatal("secret file should be read lazily")
+	}
+	if service.Get("TEST_DB_PASSWORD") != "p@ss" || !service.IsSecret("TEST_DB_PASSWORD") || service.Source("TEST_DB_PASSWORD") != secret {
+		t.Fatalf("unexpected secret: %q from %s", service.Get("TEST_DB_PASSWORD"), service.Source("TEST_DB_PASSWORD"))
+	}
+	if service.All()["TEST_DB_PASSWORD"] != "p@ss" {
+		t.Fatal("resolved secret should be in All")
+	}
+	// 已经设置的环境变量不会被密钥文件覆盖
+	if service.Get("TEST_DB_USER") != "root" || service.IsSecret("TEST_DB_USER") {
+		t.Fatalf("unexpected user: %q", service.Get("TEST_DB_USER"))
+	}
+	// 密钥文件读取失败的时候，环境变量不存在，通过 SecretError 获取失败的原因
+	if service.IsExist("TEST_APP_LOG") || service.SecretError("TEST_APP_LOG") == nil {
+		t.Fatal("expect secret error for missing file")
+	}
+	if service.SecretError("TEST_DB_PASSWORD") != nil {
+		t.Fatal("unexpected secret error")
+	}
+
+	// 关闭之后不读取密钥文件
+	instance, err = New(framework.NewGoWebContainer(), folder, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if instance.(*Service).IsExist("TEST_DB_PASSWORD") {
+		t.Fatal("secret files should be disabled")
+	}
+}
+
+func TestSecretFiles_Check(t *testing.T) {
+	folder := t.TempDir()
+	Declare(Var{Name: "TEST_SECRET_TOKEN", Required: true})
+	_ = os.Setenv("TEST_SECRET_TOKEN_FILE", filepath.Join(folder, "none"))
+	defer os.Unsetenv("TEST_SECRET_TOKEN_FILE")
+
+	instance, err := New(framework.NewGoWebContainer(), folder)
+	if err != nil {
+		t.Fatal(err)
+	}
+	service := instance.(*Service)
+	// 检查的时候报告密钥文件读取失败的原因，而不是 ErrMissing
+	for _, result := range Check(service) {
+		if result.Var.Name == "TEST_SECRET_TOKEN" && (result.Err == nil || result.Err != service.SecretError("TEST_SECRET_TOKEN")) {
+			t.Fatalf("expect secret error in check, got %v", result.Err)
+		}
+	}
+}
@@ -1,83 +1,157 @@
 package env
 
 import (
-	"bufio"
 	"errors"
 	"github.com/wxsatellite/goweb/framework"
-	"io"
+	"io/ioutil"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 )
 
 type Service struct {
 	folder    string // .env所在的目录
 	container framework.Container
 	envs      map[string]string // 保存所有的环境变量
+	sources   map[string]string // 保存每个环境变量的来源
+
+	secretFileSuffix string           // 密钥文件环境变量的后缀，为空的时候不读取密钥文件
+	secrets          map[string]bool  // 从密钥文件中读取的环境变量
+	secretTried      map[string]bool  // 已经尝试读取过密钥文件的环境变量
+	secretErrors     map[string]error // 读取密钥文件失败的原因
+
+	// 密钥文件是在获取环境变量的时候才读取的，需要加锁
+	lock sync.RWMutex
 }
 
+// New 按顺序加载 .env、.env.<APP_ENV>、.env.local，后加载的覆盖先加载的，运行时设置的环境变量优先级最高
+// .env.local 用于本地开发时的个人配置，不应该提交到代码仓库
+// 文件不存在的时候忽略，文件格式错误的时候返回带行号的错误
+// 第三个参数为可选的密钥文件后缀，默认为 DefaultSecretFileSuffix，为空字符串的时候不读取密钥文件
 func New(params ...interface{}) (interface{}, error) {
-	if len(params) != 2 {
+	if len(params) != 2 && len(params) != 3 {
 		return nil, errors.New("param error")
 	}
 	container := params[0].(framework.Container)
 	folder := params[1].(string)
+	secretFileSuffix := DefaultSecretFileSuffix
+	if len(params) == 3 {
+		secretFileSuffix = params[2].(string)
+	}
 	server := &Service{
 		container: container,
 		folder:    folder,
 		// APP_ENV 参考 vue 和 laravel 都会设置这么一个固定的环境变量，同时预设了三个模式：开发、测试、生产
-		envs: map[string]string{"APP_ENV": Development},
-	}
+		envs:    map[string]string{"APP_ENV": Development},
+		sources: map[string]string{"APP_ENV": SourceDefault},
 
-	// 解析 .env 文件
-	file := filepath.Join(folder, ".env")
-
-	// 打开 .env 文件，并获取默认环境变量
-	fd, err := os.Open(file)
-	if err == nil {
-		defer func() {
-			_ = fd.Close()
-		}()
-		br := bufio.NewReader(fd)
-		for {
-			line, _, c := br.ReadLine()
-			if c == io.EOF {
-				break
-			}
-			// APP_ENV=2
-			res := strings.Split(string(line), "=")
-			// 不符合规范，过滤
-			if len(res) < 2 {
-				continue
-			}
-			server.envs[strings.TrimSpace(res[0])] = strings.TrimSpace(res[1])
-		}
+		secretFileSuffix: secretFileSuffix,
+		secrets:          make(map[string]bool),
+		secretTried:      make(map[string]bool),
+		secretErrors:     make(map[string]error),
 	}
 
 	// 获取运行时设置的环境变量，会覆盖 .env
+	environ := make(map[string]string)
 	for _, e := range os.Environ() {
-		res := strings.Split(e, "=")
-		// 不符合规范，过滤
-		if len(res) < 2 {
+		// 值中可能也有 =，只按第一个 = 分割，Windows 下会有 =C:=C:\ 这样没有名字的变量，过滤掉
+		index := strings.Index(e, "=")
+		if index <= 0 {
 			continue
 		}
-		server.envs[strings.TrimSpace(res[0])] = strings.TrimSpace(res[1])
+		environ[e[:index]] = e[index+1:]
+	}
+
+	// 解析 .env 文件，${VAR} 先从运行环境变量中查找，再从已经加载的变量中查找
+	lookup := func(key string) (string, bool) {
+		if val, ok := environ[key]; ok {
+			return val, true
+		}
+		val, ok := server.envs[key]
+		return val, ok
+	}
+	if err := server.loadFile(".env", lookup); err != nil {
+		return nil, err
+	}
+	// 当前环境由运行环境变量或者 .env 中的 APP_ENV 决定
+	appEnv, ok := environ["APP_ENV"]
+	if !ok {
+		appEnv = server.envs["APP_ENV"]
+	}
+	if appEnv != "" {
+		if err := server.loadFile(".env."+appEnv, lookup); err != nil {
+			return nil, err
+		}
+	}
+	if err := server.loadFile(".env.local", lookup); err != nil {
+		return nil, err
+	}
+
+	for key, val := range environ {
+		server.envs[key] = val
+		server.sources[key] = SourceEnviron
 	}
 	return server, nil
 }
 
+// loadFile 加载 .env 目录下的一个文件，文件不存在的时候忽略
+func (s *Service) loadFile(name string, lookup func(string) (string, bool)) error {
+	file := filepath.Join(s.folder, name)
+	content, err := ioutil.ReadFile(file)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	err = parseDotenv(string(content), lookup, func(key string, val string) {
+		s.envs[key] = val
+		s.sources[key] = file
+	})
+	if perr, ok := err.(*ParseError); ok {
+		perr.File = file
+	}
+	return err
+}
+
 func (s *Service) AppEnv() string {
 	return s.Get("APP_ENV")
 }
 
 func (s *Service) Get(key string) (value string) {
+	s.resolveSecret(key)
+	s.lock.RLock()
+	defer s.lock.RUnlock()
 	value = s.envs[key]
 	return
 }
+
+// All 获取所有的环境变量，KEY_FILE 密钥文件只有在获取过 KEY 之后才会包含在里面
 func (s *Service) All() map[string]string {
-	return s.envs
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	all := make(map[string]string, len(s.envs))
+	for key, val := range s.envs {
+		all[key] = val
+	}
+	return all
 }
 func (s *Service) IsExist(key string) (ok bool) {
+	s.resolveSecret(key)
+	s.lock.RLock()
+	defer s.lock.RUnlock()
 	_, ok = s.envs[key]
 	return
 }
+
+func (s *Service) Source(key string) string {
+	s.resolveSecret(key)
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return s.sources[key]
+}
+
+func (s *Service) Load(val interface{}) error {
+	return Decode(s, val)
+}
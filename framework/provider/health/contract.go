@@ -0,0 +1,56 @@
+package health
+
+import "context"
+
+const Key = "goweb:health"
+
+// CheckerTag 服务提供者打上这个标签之后，如果它的服务实现了 Checkable，就绪检查的时候会自动执行，检查项的名字为服务凭证
+const CheckerTag = "goweb:health:checker"
+
+const (
+	// StatusOk 检查通过
+	StatusOk = "ok"
+	// StatusFail 检查失败
+	StatusFail = "fail"
+)
+
+// Checker 一个检查项，返回 error 表示检查失败，ctx 超时之后需要尽快返回
+type Checker func(ctx context.Context) error
+
+// Checkable 服务可以实现这个接口，配合 CheckerTag 标签自动注册为就绪检查项
+type Checkable interface {
+	HealthCheck(ctx context.Context) error
+}
+
+// Health 健康检查分为两类：
+// 存活检查（liveness），对应 /healthz，失败表示进程已经不可用，需要重启
+// 就绪检查（readiness），对应 /readyz 和 goweb health 命令，失败表示暂时不能接收流量，比如配置没有加载、日志不可写、分布式锁不可用
+type Health interface {
+	// AddChecker 注册一个就绪检查项，同名的会被替换
+	AddChecker(name string, checker Checker)
+	// AddLivenessChecker 注册一个存活检查项，同名的会被替换
+	AddLivenessChecker(name string, checker Checker)
+	// Liveness 执行所有的存活检查
+	Liveness(ctx context.Context) Report
+	// Readiness 执行所有的就绪检查，包括打了 CheckerTag 标签的服务
+	Readiness(ctx context.Context) Report
+}
+
+// Report 检查结果
+type Report struct {
+	Status string        `json:"status"`
+	Checks []CheckResult `json:"checks"`
+}
+
+// CheckResult 一个检查项的结果
+type CheckResult struct {
+	Name    string `json:"name"`
+	Status  string `json:"status"`
+	Error   string `json:"error,omitempty"`
+	Elapsed string `json:"elapsed"` // 检查的耗时，例如 1.2ms
+}
+
+// IsOk 所有的检查项都通过
+func (r Report) IsOk() bool {
+	return r.Status == StatusOk
+}
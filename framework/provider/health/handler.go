@@ -0,0 +1,44 @@
+package health
+
+import (
+	"github.com/wxsatellite/goweb/framework/gin"
+	"net/http"
+)
+
+// RegisterRoutes 在 Web 引擎上挂载 /healthz 和 /readyz，负载均衡通过这两个地址判断实例是否存活、是否可以接收流量
+func RegisterRoutes(engine *gin.Engine) {
+	engine.GET("/healthz", LivenessHandler())
+	engine.GET("/readyz", ReadinessHandler())
+}
+
+// LivenessHandler 存活检查，全部通过返回 200，否则返回 503
+func LivenessHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		service, err := c.Make(Key)
+		if err != nil {
+			c.JSON(http.StatusServiceUnavailable, Report{Status: StatusFail})
+			return
+		}
+		writeReport(c, service.(Health).Liveness(c.Request.Context()))
+	}
+}
+
+// ReadinessHandler 就绪检查，全部通过返回 200，否则返回 503
+func ReadinessHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		service, err := c.Make(Key)
+		if err != nil {
+			c.JSON(http.StatusServiceUnavailable, Report{Status: StatusFail})
+			return
+		}
+		writeReport(c, service.(Health).Readiness(c.Request.Context()))
+	}
+}
+
+func writeReport(c *gin.Context, report Report) {
+	if report.IsOk() {
+		c.JSON(http.StatusOK, report)
+		return
+	}
+	c.JSON(http.StatusServiceUnavailable, report)
+}
@@ -0,0 +1,34 @@
+package health
+
+import (
+	"github.com/wxsatellite/goweb/framework"
+	"time"
+)
+
+type Provider struct {
+	// Timeout 每个检查项的超时时间，默认 3 秒
+	Timeout time.Duration
+}
+
+func (p *Provider) Register(container framework.Container) framework.NewInstance {
+	return New
+}
+
+func (p *Provider) Boot(container framework.Container) error {
+	if p.Timeout == 0 {
+		p.Timeout = DefaultTimeout
+	}
+	return nil
+}
+
+func (p *Provider) Params(container framework.Container) []interface{} {
+	return []interface{}{container, p.Timeout}
+}
+
+func (p *Provider) IsDefer() bool {
+	return false
+}
+
+func (p *Provider) Name() string {
+	return Key
+}
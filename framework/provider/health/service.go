@@ -0,0 +1,161 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/wxsatellite/goweb/framework"
+	"sync"
+	"time"
+)
+
+// DefaultTimeout 每个检查项默认的超时时间
+const DefaultTimeout = 3 * time.Second
+
+// namedChecker 带名字的检查项，按照注册的顺序输出
+type namedChecker struct {
+	name    string
+	checker Checker
+}
+
+type Service struct {
+	container framework.Container
+	timeout   time.Duration
+
+	liveness  []namedChecker
+	readiness []namedChecker
+	lock      sync.RWMutex
+}
+
+func New(params ...interface{}) (interface{}, error) {
+	if len(params) != 2 {
+		return nil, errors.New("param error")
+	}
+	container := params[0].(framework.Container)
+	timeout := params[1].(time.Duration)
+	return &Service{container: container, timeout: timeout}, nil
+}
+
+func (s *Service) AddChecker(name string, checker Checker) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.readiness = addChecker(s.readiness, name, checker)
+}
+
+func (s *Service) AddLivenessChecker(name string, checker Checker) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.liveness = addChecker(s.liveness, name, checker)
+}
+
+// addChecker 同名替换，否则追加到最后
+func addChecker(checkers []namedChecker, name string, checker Checker) []namedChecker {
+	for i := range checkers {
+		if checkers[i].name == name {
+			checkers[i].checker = checker
+			return checkers
+		}
+	}
+	return append(checkers, namedChecker{name: name, checker: checker})
+}
+
+func (s *Service) Liveness(ctx context.Context) Report {
+	s.lock.RLock()
+	checkers := append([]namedChecker{}, s.liveness...)
+	s.lock.RUnlock()
+	return s.run(ctx, checkers)
+}
+
+func (s *Service) Readiness(ctx context.Context) Report {
+	s.lock.RLock()
+	checkers := append([]namedChecker{}, s.readiness...)
+	s.lock.RUnlock()
+	return s.run(ctx, append(s.taggedCheckers(), checkers...))
+}
+
+// taggedCheckers 打了 CheckerTag 标签的服务，能实例化并且实现了 Checkable 就执行它的检查
+func (s *Service) taggedCheckers() (checkers []namedChecker) {
+	for _, info := range s.container.Providers() {
+		if !hasTag(info.Tags, CheckerTag) {
+			continue
+		}
+		key := info.Key
+		checkers = append(checkers, namedChecker{name: key, checker: func(ctx context.Context) error {
+			instance, err := s.container.Make(key)
+			if err != nil {
+				return err
+			}
+			if checkable, ok := instance.(Checkable); ok {
+				return checkable.HealthCheck(ctx)
+			}
+			return nil
+		}})
+	}
+	return
+}
+
+func hasTag(tags []string, tag string) bool {
+	for _, t := range tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// run 并发执行所有检查项，每个检查项单独计算超时
+func (s *Service) run(ctx context.Context, checkers []namedChecker) Report {
+	report := Report{Status: StatusOk, Checks: make([]CheckResult, len(checkers))}
+
+	var wg sync.WaitGroup
+	for i, checker := range checkers {
+		wg.Add(1)
+		go func(i int, checker namedChecker) {
+			defer wg.Done()
+			report.Checks[i] = s.check(ctx, checker)
+		}(i, checker)
+	}
+	wg.Wait()
+
+	for _, result := range report.Checks {
+		if result.Status != StatusOk {
+			report.Status = StatusFail
+			break
+		}
+	}
+	return report
+}
+
+// check 执行一个检查项，检查项超时或者 panic 都算失败
+func (s *Service) check(ctx context.Context, checker namedChecker) CheckResult {
+	if s.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.timeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if p := recover(); p != nil {
+				done <- fmt.Errorf("panic: %v", p)
+			}
+		}()
+		done <- checker.checker(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	result := CheckResult{Name: checker.name, Status: StatusOk, Elapsed: time.Since(start).String()}
+	if err != nil {
+		result.Status = StatusFail
+		result.Error = err.Error()
+	}
+	return result
+}
@@ -0,0 +1,63 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"github.com/wxsatellite/goweb/framework"
+	"github.com/wxsatellite/goweb/framework/gin"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// checkableService 实现了 Checkable 的测试服务
+type checkableService struct {
+	err error
+}
+
+func (s *checkableService) HealthCheck(ctx context.Context) error {
+	return s.err
+}
+
+func TestService_Readiness(t *testing.T) {
+	container := framework.NewGoWebContainer()
+	_ = container.Bind(&Provider{Timeout: 50 * time.Millisecond})
+	container.Fake("goweb:db", &checkableService{err: errors.New("db unreachable")})
+	container.Tag(CheckerTag, "goweb:db")
+
+	service := container.MustMake(Key).(Health)
+	service.AddChecker("config", func(ctx context.Context) error {
+		return nil
+	})
+	service.AddChecker("slow", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	report := service.Readiness(context.Background())
+	if report.IsOk() || len(report.Checks) != 3 {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+	expect := map[string]string{"goweb:db": StatusFail, "config": StatusOk, "slow": StatusFail}
+	for _, result := range report.Checks {
+		if expect[result.Name] != result.Status {
+			t.Fatalf("unexpected result: %+v", result)
+		}
+	}
+	if !service.Liveness(context.Background()).IsOk() {
+		t.Fatal("liveness without checkers should be ok")
+	}
+
+	// HTTP 接口：存活检查通过返回 200，就绪检查失败返回 503
+	engine := gin.New()
+	engine.SetContainer(container)
+	RegisterRoutes(engine)
+	for path, code := range map[string]int{"/healthz": http.StatusOK, "/readyz": http.StatusServiceUnavailable} {
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		if w.Code != code {
+			t.Fatalf("%s: expect %d, got %d", path, code, w.Code)
+		}
+	}
+}
@@ -20,7 +20,9 @@ func (p *Provider) Boot(container framework.Container) error {
 	}
 	// engine 创建的时候其实会初始化container容器，这里需要进行覆盖
 	p.Engine.SetContainer(container)
-	return nil
+	// 容器设置好之后再注入控制器依赖的服务，服务没有绑定或者类型不匹配的时候，绑定 kernel 服务的 Bind 直接返回错误
+	// 控制器依赖的服务不作为 kernel 的依赖声明，否则依赖没有绑定的时候 kernel 会一直等待，直到第一次获取的时候才报错
+	return p.Engine.ResolveInjects()
 }
 
 func (p *Provider) Params(container framework.Container) []interface{} {
@@ -0,0 +1,23 @@
+package kernel
+
+import (
+	"github.com/wxsatellite/goweb/framework"
+	"github.com/wxsatellite/goweb/framework/gin"
+	"strings"
+	"testing"
+)
+
+type testController struct {
+	Missing interface{} `inject:"goweb:missing"`
+}
+
+func TestProvider_MissingInject(t *testing.T) {
+	engine := gin.New()
+	engine.Inject(&testController{})
+
+	container := framework.NewGoWebContainer()
+	err := container.Bind(&Provider{Engine: engine})
+	if err == nil || !strings.Contains(err.Error(), "contract goweb:missing have not register") {
+		t.Fatalf("expect missing inject error on bind, got: %v", err)
+	}
+}
@@ -2,15 +2,19 @@ package log
 
 import (
 	"context"
+	"github.com/pkg/errors"
 	"github.com/wxsatellite/goweb/framework"
 	"github.com/wxsatellite/goweb/framework/provider/log/formatter"
 	"io"
+	"io/ioutil"
 	"log"
+	"os"
+	"sync/atomic"
 	"time"
 )
 
 type BaseService struct {
-	level      Level               // 日志级别
+	level      Level               // 日志级别，配置热更新的时候会修改，需要原子读写
 	formatter  Formatter           // 日志格式化方法
 	ctxFielder CtxFielder          // ctx获取上下文字段
 	output     io.Writer           // 输出
@@ -19,7 +23,7 @@ type BaseService struct {
 
 // level 越小级别越大
 func (s *BaseService) CanLog(level Level) bool {
-	return s.level >= level
+	return Level(atomic.LoadUint32((*uint32)(&s.level))) >= level
 }
 
 // logf 先判断日志级别是否符合要求，如果不符合要求，则直接返回，不进行打印；
@@ -107,7 +111,7 @@ func (s *BaseService) SetOutput(output io.Writer) {
 
 // SetLevel 设置日志级别
 func (s *BaseService) SetLevel(level Level) {
-	s.level = level
+	atomic.StoreUint32((*uint32)(&s.level), uint32(level))
 }
 
 // SetFormatter 设置序列化函数
@@ -119,3 +123,13 @@ func (s *BaseService) SetFormatter(formatter Formatter) {
 func (s *BaseService) SetCtxFielder(handler CtxFielder) {
 	s.ctxFielder = handler
 }
+
+// checkFolderWritable 在目录中创建并删除一个临时文件，判断目录是否可写
+func checkFolderWritable(folder string) error {
+	f, err := ioutil.TempFile(folder, ".health")
+	if err != nil {
+		return errors.Wrap(err, "log folder is not writable")
+	}
+	_ = f.Close()
+	return os.Remove(f.Name())
+}
@@ -0,0 +1,90 @@
+package log
+
+import (
+	"context"
+	"io"
+	"sync"
+)
+
+// FakeEntry 测试日志服务记录的一条日志
+type FakeEntry struct {
+	Level  Level
+	Msg    string
+	Fields map[string]interface{}
+}
+
+// FakeService 把日志记录在内存中的日志服务，用于测试中断言输出了哪些日志
+type FakeService struct {
+	level   Level
+	entries []FakeEntry
+	lock    sync.Mutex
+}
+
+var _ Log = (*FakeService)(nil)
+
+// NewFakeService 创建测试日志服务，默认记录所有级别的日志
+func NewFakeService() *FakeService {
+	return &FakeService{level: TraceLevel}
+}
+
+// Entries 返回记录的所有日志
+func (s *FakeService) Entries() []FakeEntry {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return append([]FakeEntry{}, s.entries...)
+}
+
+func (s *FakeService) record(level Level, msg string, fields map[string]interface{}) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.level < level {
+		return
+	}
+	s.entries = append(s.entries, FakeEntry{Level: level, Msg: msg, Fields: fields})
+}
+
+func (s *FakeService) Panic(ctx context.Context, msg string, fields map[string]interface{}) {
+	s.record(PanicLevel, msg, fields)
+}
+
+func (s *FakeService) Fatal(ctx context.Context, msg string, fields map[string]interface{}) {
+	s.record(FatalLevel, msg, fields)
+}
+
+func (s *FakeService) Error(ctx context.Context, msg string, fields map[string]interface{}) {
+	s.record(ErrorLevel, msg, fields)
+}
+
+func (s *FakeService) Warn(ctx context.Context, msg string, fields map[string]interface{}) {
+	s.record(WarnLevel, msg, fields)
+}
+
+func (s *FakeService) Info(ctx context.Context, msg string, fields map[string]interface{}) {
+	s.record(InfoLevel, msg, fields)
+}
+
+func (s *FakeService) Debug(ctx context.Context, msg string, fields map[string]interface{}) {
+	s.record(DebugLevel, msg, fields)
+}
+
+func (s *FakeService) Trace(ctx context.Context, msg string, fields map[string]interface{}) {
+	s.record(TraceLevel, msg, fields)
+}
+
+func (s *FakeService) SetLevel(level Level) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.level = level
+}
+
+// SetCtxFielder 测试日志服务不需要从 context 中获取字段
+func (s *FakeService) SetCtxFielder(handler CtxFielder) {
+}
+
+// SetFormatter 测试日志服务直接记录原始信息，不需要格式化
+func (s *FakeService) SetFormatter(formatter Formatter) {
+}
+
+// SetOutput 测试日志服务记录在内存中，不需要输出管道
+func (s *FakeService) SetOutput(out io.Writer) {
+}
@@ -1,12 +1,16 @@
 package log
 
 import (
+	"github.com/spf13/cast"
 	"github.com/wxsatellite/goweb/framework"
+	"github.com/wxsatellite/goweb/framework/provider/app"
 	"github.com/wxsatellite/goweb/framework/provider/config"
+	"github.com/wxsatellite/goweb/framework/provider/health"
 	"github.com/wxsatellite/goweb/framework/provider/log/formatter"
 	"io"
 	"os"
 	"strings"
+	"sync"
 )
 
 type Provider struct {
@@ -20,6 +24,13 @@ type Provider struct {
 	CtxFielder CtxFielder
 	// 日志输出管道
 	Output io.Writer
+
+	// hookOnce 保证容器耗时钩子只添加一次，MakeNew 也会调用 Boot
+	hookOnce sync.Once
+	// followConfig 没有指定日志级别的时候，日志级别跟随配置文件中的 log.level 热更新
+	followConfig bool
+	// watchOnce 保证 log.level 只订阅一次
+	watchOnce sync.Once
 }
 
 func (p *Provider) Register(container framework.Container) framework.NewInstance {
@@ -46,8 +57,36 @@ func (p *Provider) Register(container framework.Container) framework.NewInstance
 	}
 }
 
-func (p *Provider) Boot(container framework.Container) error {
-	return nil
+// Tags 日志服务参与就绪检查，检查日志目录是否可写
+func (p *Provider) Tags() []string {
+	return []string{health.CheckerTag}
+}
+
+// Depends 日志服务从 config 服务中读取驱动、级别等配置，文件类的驱动还需要 app 服务获取日志目录
+func (p *Provider) Depends() []string {
+	return []string{app.Key, config.Key}
+}
+
+// Boot 添加容器事件钩子，通过日志服务输出每个服务 Boot、实例化的耗时
+// Boot 在 Params 之前调用，Params 会填充 Level，所以在这里记录是否需要跟随配置
+func (p *Provider) Boot(container framework.Container) (err error) {
+	p.hookOnce.Do(func() {
+		container.AddHook(TimingHook())
+		p.followConfig = p.Level == 0
+	})
+	return
+}
+
+// watchLevel 订阅配置文件中 log.level 的变化，修改日志服务单例的级别
+// 回调在配置服务的监听协程中执行，这时日志服务已经实例化完成，通过容器获取不会死锁
+func watchLevel(container framework.Container, configService config.Config) {
+	configService.Watch("log.level", func(key string, oldVal interface{}, newVal interface{}) {
+		instance, err := container.Make(Key)
+		if err != nil {
+			return
+		}
+		instance.(Log).SetLevel(logLevel(cast.ToString(newVal)))
+	})
 }
 
 func (p *Provider) IsDefer() bool {
@@ -69,6 +108,11 @@ func (p *Provider) Params(container framework.Container) []interface{} {
 			p.Level = logLevel(configService.GetString("log.level"))
 		}
 	}
+	if p.followConfig {
+		p.watchOnce.Do(func() {
+			watchLevel(container, configService)
+		})
+	}
 
 	if p.Formatter == nil {
 		p.Formatter = formatter.TextFormatter
@@ -0,0 +1,45 @@
+package log
+
+import (
+	"github.com/wxsatellite/goweb/framework"
+	"github.com/wxsatellite/goweb/framework/provider/app"
+	"github.com/wxsatellite/goweb/framework/provider/config"
+	"testing"
+	"time"
+)
+
+func TestProvider_WatchLevel(t *testing.T) {
+	container := framework.NewGoWebContainer()
+	container.Fake(app.Key, app.NewFakeService(t.TempDir()))
+	configService := config.NewFakeService(map[string]interface{}{
+		"log": map[string]interface{}{"driver": "console", "level": "info"},
+	})
+	container.Fake(config.Key, configService)
+
+	// 绑定真实的日志服务提供者，Boot 中不能再通过容器获取自己，否则会死锁
+	done := make(chan error, 1)
+	go func() {
+		done <- container.Bind(&Provider{})
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("bind log provider deadlocked")
+	}
+
+	logService := container.MustMake(Key).(*ConsoleService)
+	if logService.CanLog(DebugLevel) {
+		t.Fatal("debug should be disabled at info level")
+	}
+	// MakeNew 不会再订阅一次
+	if _, err := container.MakeNew(Key, nil); err != nil {
+		t.Fatal(err)
+	}
+	configService.Set("log.level", "debug")
+	if !logService.CanLog(DebugLevel) {
+		t.Fatal("log level should follow config")
+	}
+}
@@ -1,6 +1,7 @@
 package log
 
 import (
+	"context"
 	"fmt"
 	rotatelogs "github.com/lestrrat-go/file-rotatelogs"
 	"github.com/pkg/errors"
@@ -19,6 +20,7 @@ type RotateService struct {
 
 	folder string
 	file   string
+	writer *rotatelogs.RotateLogs // 切割日志的输出管道
 }
 
 // 第三方库 github.com/lestrrat-go/file-rotatelogs 有日志切割功能
@@ -97,7 +99,21 @@ func NewRotateService(params ...interface{}) (interface{}, error) {
 	if err != nil {
 		return nil, errors.Wrap(err, "new rotatelogs error")
 	}
+	log.writer = w
 	log.SetOutput(w)
 	log.container = container
 	return log, nil
 }
+
+// Close 关闭当前正在写入的日志文件，容器 Shutdown 的时候调用
+func (s *RotateService) Close() error {
+	if s.writer == nil {
+		return nil
+	}
+	return s.writer.Close()
+}
+
+// HealthCheck 就绪检查：日志目录是否可写
+func (s *RotateService) HealthCheck(ctx context.Context) error {
+	return checkFolderWritable(s.folder)
+}
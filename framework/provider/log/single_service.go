@@ -1,6 +1,7 @@
 package log
 
 import (
+	"context"
 	"github.com/pkg/errors"
 	"github.com/wxsatellite/goweb/framework"
 	"github.com/wxsatellite/goweb/framework/provider/app"
@@ -14,8 +15,9 @@ import (
 type SingleService struct {
 	BaseService
 
-	folder string // 日志文件存储目录
-	file   string // 日志文件名称
+	folder string   // 日志文件存储目录
+	file   string   // 日志文件名称
+	fd     *os.File // 日志文件句柄
 }
 
 func NewSingleService(params ...interface{}) (interface{}, error) {
@@ -61,7 +63,22 @@ func NewSingleService(params ...interface{}) (interface{}, error) {
 	}
 
 	// 设置输出管道
+	service.fd = fd
 	service.SetOutput(fd)
 	service.container = container
 	return service, nil
 }
+
+// Close 将日志刷到磁盘并关闭文件句柄，容器 Shutdown 的时候调用
+func (s *SingleService) Close() error {
+	if s.fd == nil {
+		return nil
+	}
+	_ = s.fd.Sync()
+	return s.fd.Close()
+}
+
+// HealthCheck 就绪检查：日志目录是否可写
+func (s *SingleService) HealthCheck(ctx context.Context) error {
+	return checkFolderWritable(s.folder)
+}
@@ -0,0 +1,60 @@
+package log
+
+import (
+	"context"
+	"github.com/wxsatellite/goweb/framework"
+	"sync"
+)
+
+// maxBufferedEvents 日志服务实例化之前最多缓存的容器事件个数
+const maxBufferedEvents = 512
+
+// TimingHook 返回一个容器事件钩子，通过日志服务以 debug 级别输出每个服务绑定、Boot、实例化的耗时，出错的时候输出 error 日志。
+// 日志服务实例化之前的事件会先缓存起来，等日志服务实例化之后再一起输出
+func TimingHook() framework.Hook {
+	var (
+		lock   sync.Mutex
+		logger Log
+		buffer []framework.Event
+	)
+	return func(event framework.Event) {
+		lock.Lock()
+		defer lock.Unlock()
+
+		if logger == nil {
+			instance, ok := event.Instance.(Log)
+			if !ok || event.Type != framework.EventAfterInstantiate || event.Key != Key {
+				if len(buffer) < maxBufferedEvents {
+					buffer = append(buffer, event)
+				}
+				return
+			}
+			// 日志服务实例化完成，输出之前缓存的事件
+			logger = instance
+			for _, e := range buffer {
+				logEvent(logger, e)
+			}
+			buffer = nil
+		}
+		logEvent(logger, event)
+	}
+}
+
+// logEvent 输出一个容器事件
+func logEvent(logger Log, event framework.Event) {
+	fields := map[string]interface{}{"key": event.Key}
+	switch event.Type {
+	case framework.EventBind:
+		logger.Debug(context.Background(), "container bind", fields)
+	case framework.EventAfterBoot:
+		fields["elapsed"] = event.Elapsed.String()
+		logger.Debug(context.Background(), "container boot", fields)
+	case framework.EventAfterInstantiate:
+		fields["elapsed"] = event.Elapsed.String()
+		logger.Debug(context.Background(), "container instantiate", fields)
+	case framework.EventError:
+		fields["elapsed"] = event.Elapsed.String()
+		fields["error"] = event.Err.Error()
+		logger.Error(context.Background(), "container error", fields)
+	}
+}